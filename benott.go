@@ -2,21 +2,93 @@ package benott
 
 import (
 	"container/heap"
+	"iter"
 	"math"
+	"slices"
 	"sort"
 	"sync"
 )
 
-// This pool allows us to reuse Event structs instead of allocating new ones on the
-// heap for every endpoint and intersection. This dramatically reduces GC pressure.
-var eventPool = sync.Pool{
+// This pool allows us to reuse Sweepers, and with them the Event structs and
+// buffers of the sweep, instead of allocating new ones on the heap for every
+// call. This dramatically reduces GC pressure.
+var sweeperPool = sync.Pool{
 	New: func() any {
-		return &Event{}
+		return new(Sweeper)
 	},
 }
 
+// IntersectionKind distinguishes the kinds of results reported by the sweep.
+type IntersectionKind int
+
+const (
+	// PointIntersection is a single point where two or more segments meet.
+	PointIntersection IntersectionKind = iota
+	// CollinearOverlap is a sub-segment shared by two collinear segments. It is
+	// only reported when Options.ReportOverlaps is set.
+	CollinearOverlap
+)
+
+// IntersectionResult describes a single intersection found by the sweep.
+type IntersectionResult struct {
+	// Kind tells whether the result is a point or a collinear overlap.
+	Kind IntersectionKind
+	// Point is the location of the intersection. For an overlap it is the
+	// leftmost (then lowest) end of the shared sub-segment.
+	Point Point
+	// Segments holds the indices, in ascending order, of every input segment
+	// passing through Point, or of the two overlapping segments.
+	Segments []int
+	// Overlap is the shared sub-segment of a CollinearOverlap result, ordered
+	// like the sweep. It is zero for point intersections.
+	Overlap [2]Point
+}
+
+// meeting is an intersection as found by the sweep, before it is turned into
+// a result. The segs slice is reused by the sweep and must not be retained.
+type meeting struct {
+	kind IntersectionKind
+	// point is the intersection point, or the start of an overlap.
+	point Point
+	// end is the end of an overlap.
+	end Point
+	// segs holds all segments through point, or the two overlapping segments.
+	segs []*Segment
+	// pairs holds the counted pairs among segs.
+	pairs [][2]*Segment
+}
+
+// result converts the meeting into an IntersectionResult owned by the caller.
+func (m *meeting) result() IntersectionResult {
+	indices := make([]int, len(m.segs))
+	for i, seg := range m.segs {
+		indices[i] = seg.index
+	}
+	sort.Ints(indices)
+	r := IntersectionResult{Kind: m.kind, Point: m.point, Segments: indices}
+	if m.kind == CollinearOverlap {
+		r.Overlap = [2]Point{m.point, m.end}
+	}
+	return r
+}
+
+// Stats summarizes the intersections in a set of segments.
+type Stats struct {
+	// Points is the number of distinct intersection points.
+	Points int
+	// Pairs is the number of intersecting pairs of segments. A point where k
+	// segments meet contributes k*(k-1)/2 pairs, and each collinear overlap,
+	// when reported, contributes one.
+	Pairs int
+	// MaxMultiplicity is the largest number of segments meeting at a single
+	// intersection point, or 0 if there are none.
+	MaxMultiplicity int
+}
+
 // CountIntersections implements the Bentley-Ottmann algorithm to find the total
-// number of intersection points in a given set of line segments.
+// number of intersecting pairs in a given set of line segments. If k segments
+// meet at a single point, they form k*(k-1)/2 pairs; use IntersectionStats to
+// count the distinct points as well.
 //
 // The algorithm uses a sweep-line approach, processing events (segment endpoints
 // and intersections) from left to right. It maintains a status structure (a
@@ -30,17 +102,167 @@ var eventPool = sync.Pool{
 // It correctly handles complex cases, including vertical segments and multiple
 // segments intersecting at a single point.
 func CountIntersections(segments []Segment) int {
+	return CountIntersectionsWithOptions(segments, Options{})
+}
+
+// CountIntersectionsWithOptions is CountIntersections with a configurable
+// tolerance and endpoint policy. See Options for details. When overlaps are
+// reported, each overlapping pair adds one to the count.
+func CountIntersectionsWithOptions(segments []Segment, opts Options) int {
+	intersections := 0
+	sweep(segments, opts.resolve(segments), func(m *meeting) bool {
+		intersections += len(m.pairs)
+		return true
+	})
+	return intersections
+}
+
+// IntersectionStats computes, in the same single sweep as CountIntersections,
+// both the number of distinct intersection points and the number of
+// intersecting pairs.
+func IntersectionStats(segments []Segment) Stats {
+	return IntersectionStatsWithOptions(segments, Options{})
+}
+
+// IntersectionStatsWithOptions is IntersectionStats with configurable Options.
+func IntersectionStatsWithOptions(segments []Segment, opts Options) Stats {
+	var stats Stats
+	sweep(segments, opts.resolve(segments), func(m *meeting) bool {
+		stats.Pairs += len(m.pairs)
+		if m.kind == PointIntersection {
+			stats.Points++
+			stats.MaxMultiplicity = max(stats.MaxMultiplicity, len(m.segs))
+		}
+		return true
+	})
+	return stats
+}
+
+// FindIntersections runs the same sweep as CountIntersections but reports where
+// the intersections are. Each result holds an intersection point together with
+// the indices of all input segments passing through it. Results are ordered
+// from left to right, then from bottom to top.
+func FindIntersections(segments []Segment) []IntersectionResult {
+	return FindIntersectionsWithOptions(segments, Options{})
+}
+
+// FindIntersectionsWithOptions is FindIntersections with configurable Options.
+// With Options.ReportOverlaps set, collinear overlaps are reported as
+// CollinearOverlap results, at the position of their leftmost end.
+func FindIntersectionsWithOptions(segments []Segment, opts Options) []IntersectionResult {
+	return slices.Collect(IntersectionsWithOptions(segments, opts))
+}
+
+// Intersections returns an iterator over the same results as FindIntersections.
+// Each result is yielded as soon as the sweep finds it, and breaking out of the
+// loop stops the sweep, so callers that filter or aggregate intersections never
+// hold all of them in memory.
+func Intersections(segments []Segment) iter.Seq[IntersectionResult] {
+	return IntersectionsWithOptions(segments, Options{})
+}
+
+// IntersectionsWithOptions is Intersections with configurable Options.
+func IntersectionsWithOptions(segments []Segment, opts Options) iter.Seq[IntersectionResult] {
+	return func(yield func(IntersectionResult) bool) {
+		sweep(segments, opts.resolve(segments), func(m *meeting) bool {
+			return yield(m.result())
+		})
+	}
+}
+
+// IntersectingPairs returns every pair of intersecting segments as indices into
+// the input slice. Each pair is ordered so that i < j, and the pairs are sorted
+// by i, then by j, so the result is deterministic regardless of the order in
+// which the sweep discovers them.
+func IntersectingPairs(segments []Segment) [][2]int {
+	return IntersectingPairsWithOptions(segments, Options{})
+}
+
+// IntersectingPairsWithOptions is IntersectingPairs with configurable Options.
+// With Options.ReportOverlaps set, collinear overlaps are included.
+func IntersectingPairsWithOptions(segments []Segment, opts Options) [][2]int {
+	var pairs [][2]int
+	sweep(segments, opts.resolve(segments), func(m *meeting) bool {
+		for _, pair := range m.pairs {
+			pairs = append(pairs, pairIndices(pair))
+		}
+		return true
+	})
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i][0] != pairs[j][0] {
+			return pairs[i][0] < pairs[j][0]
+		}
+		return pairs[i][1] < pairs[j][1]
+	})
+	return pairs
+}
+
+// HasIntersection reports whether any two segments intersect, following the
+// Shamos-Hoey approach: the sweep stops at the first intersection point it
+// reaches, and never enumerates pairs of segments that merely share an
+// endpoint, so the worst case is O(n log n) no matter how many intersections
+// the input has. Only many overlapping collinear segments, which do not count
+// as intersecting, can make it slower. When an intersection exists, the offending pair is returned
+// as indices into the input slice with i < j. If several pairs meet at that
+// point, the lowest pair is chosen.
+func HasIntersection(segments []Segment) (bool, [2]int) {
+	found := false
+	var pair [2]int
+	sweep(segments, Options{}.resolve(segments), func(m *meeting) bool {
+		for _, p := range m.pairs {
+			ab := pairIndices(p)
+			if !found || ab[0] < pair[0] || (ab[0] == pair[0] && ab[1] < pair[1]) {
+				found, pair = true, ab
+			}
+		}
+		return false
+	})
+	return found, pair
+}
+
+// pairIndices returns the input indices of a pair of segments in ascending
+// order.
+func pairIndices(pair [2]*Segment) [2]int {
+	a, b := pair[0].index, pair[1].index
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
+
+// sweep runs the Bentley-Ottmann algorithm over segments with the tolerance
+// and policies from cfg, using a pooled Sweeper. See Sweeper.sweep.
+func sweep(segments []Segment, cfg config, visit func(m *meeting) bool) {
+	sw := sweeperPool.Get().(*Sweeper)
+	sw.sweep(segments, cfg, visit)
+	sw.release()
+	sweeperPool.Put(sw)
+}
+
+// sweep runs the Bentley-Ottmann algorithm over segments with the tolerance
+// and policies from cfg, and calls visit for every intersection it finds: each
+// point where at least one pair of segments intersects, and, if enabled, each
+// collinear overlap. Returning false from visit stops the sweep.
+func (sw *Sweeper) sweep(segments []Segment, cfg config, visit func(m *meeting) bool) {
+	if cfg.robust {
+		sw.sweepRobust(segments, cfg, visit)
+		return
+	}
+
 	// The event queue stores all segment endpoints to initialize the sweep.
-	// Pre-allocate the event queue with a known initial size.
 	// Each segment generates two initial events (start and end).
-	eq := make(EventQueue, 0, len(segments)*2)
-	segmentCopies := make([]Segment, len(segments))
-	copy(segmentCopies, segments)
+	eq := &sw.queue
+	*eq = slices.Grow((*eq)[:0], len(segments)*2)
+	sw.segments = append(sw.segments[:0], segments...)
 
 	// This single loop correctly normalizes, pre-computes, and creates events
 	// for each segment in a logical, efficient order.
-	for i := range segmentCopies {
-		s := &segmentCopies[i] // Use a pointer to modify the copy
+	for i := range sw.segments {
+		s := &sw.segments[i] // Use a pointer to modify the copy
+		s.index = i
+		if cfg.skips(s) {
+			continue
+		}
 
 		// 1. NORMALIZE FIRST: Ensure P1 is always the leftmost endpoint.
 		if s.P1.X > s.P2.X || (s.P1.X == s.P2.X && s.P1.Y > s.P2.Y) {
@@ -49,165 +271,283 @@ func CountIntersections(segments []Segment) int {
 
 		// 2. PRE-COMPUTE SECOND: Calculate properties based on the final, normalized points.
 		p1, p2 := s.P1, s.P2 // Use the now-normalized points
-		if math.Abs(p1.X-p2.X) < epsilon {
+		s.length = math.Hypot(p2.X-p1.X, p2.Y-p1.Y)
+		if math.Abs(p1.X-p2.X) < cfg.eps {
 			s.isVertical = true
 			s.slope = math.Inf(1)
-			s.yIntercept = math.NaN() // Not applicable
 		} else {
 			s.isVertical = false // Ensure this is set correctly
 			s.slope = (p2.Y - p1.Y) / (p2.X - p1.X)
-			s.yIntercept = p1.Y - s.slope*p1.X
 		}
 
-		// 3. PUSH EVENTS THIRD: Create and push the start and end events.
-		startEvent := eventPool.Get().(*Event)
-		startEvent.Point = s.P1
-		startEvent.Type = SegmentStart
-		startEvent.Seg1 = s
-		heap.Push(&eq, startEvent)
-
-		endEvent := eventPool.Get().(*Event)
-		endEvent.Point = s.P2
-		endEvent.Type = SegmentEnd
-		endEvent.Seg1 = s
-		heap.Push(&eq, endEvent)
+		// 3. PUSH EVENTS THIRD: Create and push the start and end events. A
+		// segment clipped to a slab starts where it enters the slab.
+		start := s.P1
+		if cfg.slab != nil {
+			start = cfg.slab.enter(s)
+		}
+		heap.Push(eq, sw.newEvent(start, SegmentStart, s, nil))
+		heap.Push(eq, sw.newEvent(s.P2, SegmentEnd, s, nil))
 	}
 
-	status := NewStatus()
-	intersections := 0
-
-	// Pre-allocate the slice used for sorting intersecting segments.
-	// We declare it once outside the loop and reset its length to 0 on each use.
-	// This avoids re-allocating this slice in every intersection event.
-	segsAsSlice := make([]*Segment, 0, 16) // Start with a reasonable capacity
-
-	for eq.Len() > 0 {
-		event := heap.Pop(&eq).(*Event)
+	status := sw.status
+	if status == nil {
+		status = newStatus(cfg)
+		sw.status = status
+	}
+	status.tree.clear()
+	status.comparator.eps = cfg.eps
 
-		// Set the sweep line's current position for all status comparisons.
-		status.SetX(event.Point.X)
+	// The slices used at each event point live in the Sweeper, so they are
+	// allocated once and only have their length reset on each use.
+	starts := sw.starts[:0]   // Segments whose left endpoint is the event point.
+	through := sw.through[:0] // Every segment passing through the event point.
+	after := sw.after[:0]     // Segments continuing past the event point.
+	pairs := sw.pairs[:0]     // Counted pairs at the event point.
+	defer func() {
+		sw.starts, sw.through, sw.after, sw.pairs = starts, through, after, pairs
+	}()
 
-		switch event.Type {
-		case SegmentStart:
-			// A new segment is added to the status.
-			seg := event.Seg1
-			status.Add(seg)
-			// Check for intersections with its new neighbors.
-			above, below := status.FindNeighbors(seg)
-			if above != nil {
-				checkIntersection(seg, above, event.Point, &eq)
+	col := &sw.column
+	for {
+		// 1. Take the next event from the current column, after opening a new
+		// one if it is empty: every event within the tolerance of the leftmost
+		// remaining one in X.
+		if col.Len() == 0 {
+			if eq.Len() == 0 || cfg.beyond((*eq)[0].Point) {
+				break
 			}
-			if below != nil {
-				checkIntersection(seg, below, event.Point, &eq)
+			col.x = (*eq)[0].Point.X
+			for eq.Len() > 0 && (*eq)[0].Point.X-col.x <= cfg.eps {
+				heap.Push(col, heap.Pop(eq))
 			}
+		}
+		event := heap.Pop(col).(*Event)
+		p := event.Point
 
-		case SegmentEnd:
-			// A segment is removed from the status.
-			seg := event.Seg1
-			above, below := status.FindNeighbors(seg)
-			status.Remove(seg)
-			// Its former neighbors are now adjacent; check if they intersect.
-			if above != nil && below != nil {
-				checkIntersection(above, below, event.Point, &eq)
+		// Drain every event located at this point. Only segment starts carry
+		// information the status does not already have; ends and intersections
+		// are found again below by looking at the status itself.
+		starts = starts[:0]
+		drained := 0
+		for {
+			if event.Type == SegmentStart {
+				starts = append(starts, event.Seg1)
 			}
+			// Prefer an exact input endpoint over a computed intersection point.
+			if event.Type != Intersection {
+				p = event.Point
+			}
+			if cfg.observer != nil {
+				cfg.observer.Event(event.Point, event.Type, indexOf(event.Seg1), indexOf(event.Seg2))
+			}
+			sw.freeEvent(event)
+			drained++
 
-		case Intersection:
-			// This block handles the complex case of one or more intersections
-			// occurring at the exact same point.
-
-			// 1. Identify all segments involved in intersections at this point.
-			intersectingSegs := make(map[*Segment]bool)
-			intersectingSegs[event.Seg1] = true
-			intersectingSegs[event.Seg2] = true
+			if col.Len() == 0 || !near(col.EventQueue[0].Point, p, cfg.eps) {
+				break
+			}
+			event = heap.Pop(col).(*Event)
+		}
+		// A sweep with a budget stops once it is spent or its context is done.
+		if cfg.budget != nil && !cfg.budget.spend(drained) {
+			return
+		}
 
-			// Peek at subsequent events in the queue to find all other
-			// intersections happening at this exact coordinate.
-			for eq.Len() > 0 && (*eq[0]).Point == event.Point && (*eq[0]).Type == Intersection {
-				nextEvent := heap.Pop(&eq).(*Event)
-				intersectingSegs[nextEvent.Seg1] = true
-				intersectingSegs[nextEvent.Seg2] = true
+		// 2. Find the contiguous run of segments in the status that pass through
+		// p, together with its outer neighbors.
+		status.setEvent(p)
+		var below, above *rbNode[*Segment]
+		through, below, above = status.collectAt(p, through[:0])
+		active := len(through)
+		through = append(through, starts...)
 
-				// When returning to pool, nil out pointers to prevent memory leaks.
-				nextEvent.Seg1 = nil
-				nextEvent.Seg2 = nil
-				eventPool.Put(nextEvent)
+		// 3. Count the intersecting pairs at this point.
+		if len(through) > 1 {
+			var more bool
+			pairs, more = sw.countedPairs(through, active, p, cfg, pairs[:0])
+			if len(pairs) > 0 {
+				sw.meeting = meeting{kind: PointIntersection, point: p, segs: through, pairs: pairs}
+				if !visit(&sw.meeting) {
+					return
+				}
+			}
+			if !more {
+				return
+			}
+			if cfg.overlaps && !sw.visitOverlaps(through, p, cfg, visit) {
+				return
 			}
+		}
 
-			// 2. Count all intersections. If k segments meet at one point,
-			// this creates C(k, 2) = k*(k-1)/2 intersections.
-			k := len(intersectingSegs)
-			intersections += k * (k - 1) / 2
+		// 4. Move the status past p. Segments ending at p leave the run, the
+		// rest of it is reversed in place, since those segments cross at p,
+		// and segments starting at p join it.
+		after = status.passAt(p, active, below, above, starts, after[:0])
+		if cfg.observer != nil {
+			sw.observePass(cfg.observer, p, through, active, after, cfg.eps)
+		}
 
-			// 3. Find the neighbors of the entire block of intersecting segments
-			// before their order is changed.
-			// Reset the pre-allocated slice instead of making a new one.
-			segsAsSlice = segsAsSlice[:0]
-			for seg := range intersectingSegs {
-				segsAsSlice = append(segsAsSlice, seg)
-			}
-			sort.Slice(segsAsSlice, func(i, j int) bool {
-				return status.comparator.Compare(segsAsSlice[i], segsAsSlice[j]) < 0
-			})
+		// 5. Check for new intersections between the run's new boundaries and
+		// its outer neighbors, or between the neighbors themselves if nothing
+		// continues past p.
+		lower, upper := keyOf(below), keyOf(above)
+		if len(after) == 0 {
+			sw.checkIntersection(lower, upper, p, cfg)
+		} else {
+			sw.checkIntersection(lower, after[0], p, cfg)
+			sw.checkIntersection(after[len(after)-1], upper, p, cfg)
+		}
+	}
+}
+
+// countedPairs appends to buf the counted pairs among the segments passing
+// through p, of which the first active are in the order of the status. It
+// reports whether the sweep may go on, which is false once a budget is spent;
+// buf then holds the pairs counted until then.
+func (sw *Sweeper) countedPairs(through []*Segment, active int, p Point, cfg config, buf [][2]*Segment) ([][2]*Segment, bool) {
+	inner, ends := sw.inner[:0], sw.ends[:0]
+	for i, s := range through {
+		if cfg.zeroLength(s) || !s.hasEndpoint(p, cfg.eps) {
+			inner = append(inner, i)
+		} else {
+			ends = append(ends, i)
+		}
+	}
+	sw.inner, sw.ends = inner, ends
 
-			bottomSeg, topSeg := segsAsSlice[0], segsAsSlice[k-1]
-			aboveTop, _ := status.FindNeighbors(topSeg)
-			_, belowBottom := status.FindNeighbors(bottomSeg)
+	more := candidatePairs(cfg.endpoints, inner, ends, func(i, j int) bool {
+		if i > j {
+			i, j = j, i
+		}
+		a, b := through[i], through[j]
+		// Nearly parallel segments may both pass within the tolerance of
+		// event points on either side of where they meet. If the upper one
+		// of two in the status already lies above the other past p, they met
+		// and were counted at an earlier point.
+		met := j < active && overtakes(b, a)
+		counted := (!cfg.crossLayers || a.layer != b.layer) && !met && cfg.crosses(a, b, p)
+		if counted {
+			buf = append(buf, [2]*Segment{a, b})
+		}
+		return cfg.budget == nil || cfg.budget.test(counted)
+	})
+	return buf, more
+}
 
-			// 4. Update the status by removing and re-inserting all intersecting
-			// segments. This correctly reorders them after the intersection point.
-			for _, seg := range segsAsSlice {
-				status.Remove(seg)
+// candidatePairs calls f for every pair of segments meeting at an event point
+// that may count under the endpoint policy, given those passing through the
+// point in their interior, or having zero length there, and those with an
+// endpoint there. Pairs of two segments with an endpoint at the point only
+// count under IncludeSharedEndpoints, and are not even enumerated otherwise,
+// so that segments fanning out of a shared endpoint take linear time. It
+// stops as soon as f returns false, and reports whether it did not.
+func candidatePairs[S any](policy EndpointPolicy, inner, ends []S, f func(a, b S) bool) bool {
+	for i, a := range inner {
+		for _, b := range inner[i+1:] {
+			if !f(a, b) {
+				return false
+			}
+		}
+		if policy == ProperCrossingsOnly {
+			continue
+		}
+		for _, b := range ends {
+			if !f(a, b) {
+				return false
 			}
-			status.SetX(event.Point.X + epsilon) // Use a point slightly after to get the new order
-			for i := k - 1; i >= 0; i-- {        // Re-add in their new (reversed) order
-				status.Add(segsAsSlice[i])
+		}
+	}
+	if policy == IncludeSharedEndpoints {
+		for i, a := range ends {
+			for _, b := range ends[i+1:] {
+				if !f(a, b) {
+					return false
+				}
 			}
+		}
+	}
+	return true
+}
 
-			// 5. Check for new intersections between the block's new boundaries
-			// and their old outer neighbors.
-			if aboveTop != nil {
-				checkIntersection(segsAsSlice[0], aboveTop, event.Point, &eq) // New top vs. old neighbor
+// visitOverlaps reports every collinear overlap that starts at p among the
+// segments passing through it. An overlap starts where the later of the two
+// segments starts, so each overlapping pair is reported exactly once.
+func (sw *Sweeper) visitOverlaps(through []*Segment, p Point, cfg config, visit func(m *meeting) bool) bool {
+	eps := cfg.eps
+	for i := range through {
+		for j := i + 1; j < len(through); j++ {
+			a, b := through[i], through[j]
+			if cfg.crossLayers && a.layer == b.layer {
+				continue
+			}
+			// Both segments pass through p, so parallel segments are collinear.
+			end, overlap := a.P2, false
+			if cfg.parallel(a, b) && (near(a.P1, p, eps) || near(b.P1, p, eps)) {
+				if pointLess(b.P2, a.P2) {
+					end = b.P2
+				}
+				// Segments closer than that only touch end to end.
+				overlap = math.Hypot(end.X-p.X, end.Y-p.Y) > eps
+			}
+			if overlap {
+				sw.overlap = [1][2]*Segment{{a, b}}
+				sw.meeting = meeting{kind: CollinearOverlap, point: p, end: end, segs: sw.overlap[0][:], pairs: sw.overlap[:]}
+				if !visit(&sw.meeting) {
+					return false
+				}
 			}
-			if belowBottom != nil {
-				checkIntersection(segsAsSlice[k-1], belowBottom, event.Point, &eq) // New bottom vs. old neighbor
+			if cfg.budget != nil && !cfg.budget.test(overlap) {
+				return false
 			}
 		}
-
-		// Return the processed event to the pool.
-		event.Seg1 = nil
-		event.Seg2 = nil
-		eventPool.Put(event)
 	}
-
-	return intersections
+	return true
 }
 
 // checkIntersection checks if two segments s1 and s2 intersect at a point that
 // is to the right of the current sweep line. If they do, a new Intersection
 // event is created and pushed onto the event queue.
-func checkIntersection(s1, s2 *Segment, currentPoint Point, eq *EventQueue) {
+func (sw *Sweeper) checkIntersection(s1, s2 *Segment, currentPoint Point, cfg config) {
 	if s1 == nil || s2 == nil {
 		return
 	}
-	if p, ok := s1.intersection(*s2); ok {
-		// Only add events that are in the future (to the right of the sweep line,
-		// or on the line but with a greater Y value). This prevents adding
-		// duplicate events or events that have already been processed.
-		// This condition is critical to prevent infinite loops from floating-point errors.
-		// A new intersection event is only added if its X-coordinate is *meaningfully*
-		// to the right of the current event's X-coordinate, or if it's on the same
-		// vertical line but *meaningfully* above the current event point.
-		isFutureEvent := (p.X-currentPoint.X > epsilon) ||
-			(math.Abs(p.X-currentPoint.X) < epsilon && p.Y-currentPoint.Y > epsilon)
-
-		if isFutureEvent {
-			// Get event from the pool.
-			newEvent := eventPool.Get().(*Event)
-			newEvent.Point = p
-			newEvent.Type = Intersection
-			newEvent.Seg1 = s1
-			newEvent.Seg2 = s2
-			heap.Push(eq, newEvent)
+	eps := cfg.eps
+	result := CheckDisjoint
+	p, ok := s1.intersection(*s2, cfg)
+	if ok {
+		// Only add events that are in the future: to the right of the current
+		// column, or in it but above the current event point. Both are taken
+		// as meaningfully beyond the event, by more than the tolerance. This
+		// prevents adding duplicate events or events that have already been
+		// processed, and is critical to prevent infinite loops from
+		// floating-point errors.
+		col := &sw.column
+		switch {
+		case p.X-col.x > eps:
+			heap.Push(&sw.queue, sw.newEvent(p, Intersection, s1, s2))
+			result = CheckScheduled
+		case math.Abs(p.X-col.x) <= eps && p.Y-currentPoint.Y > eps:
+			heap.Push(col, sw.newEvent(p, Intersection, s1, s2))
+			result = CheckScheduled
+		case math.Abs(p.X-col.x) <= eps && currentPoint.Y-p.Y > eps && overtakes(s1, s2):
+			// The column is swept from bottom to top, but its events are up to
+			// the tolerance apart in X. A steep segment may then meet s1 below
+			// the event point while another segment still lay between them, so
+			// that meeting was passed without swapping them. Go back to it.
+			heap.Push(col, sw.newEvent(p, Intersection, s1, s2))
+			result = CheckScheduled
+		default:
+			result = CheckNotInFuture
 		}
 	}
+	if cfg.observer != nil {
+		cfg.observer.Check(currentPoint, s1.index, s2.index, p, result)
+	}
+}
+
+// overtakes reports whether s1 has the greater slope, so that it lies above s2
+// past their meeting point. It is false if either segment is vertical.
+func overtakes(s1, s2 *Segment) bool {
+	return !s1.isVertical && !s2.isVertical && s1.slope > s2.slope
 }
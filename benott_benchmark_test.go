@@ -95,3 +95,36 @@ func BenchmarkGridSegments(b *testing.B) {
 		})
 	}
 }
+
+// BenchmarkSweeper measures repeated sweeps of a small input with a reused
+// Sweeper, the hot-loop scenario it is designed for.
+func BenchmarkSweeper(b *testing.B) {
+	segments := generateRandomSegments(100, 1000.0)
+	sw := benott.NewSweeper(benott.Options{})
+	sw.CountIntersections(segments) // Warm up the buffers.
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for b.Loop() {
+		sw.CountIntersections(segments)
+	}
+}
+
+// BenchmarkParallel measures CountIntersectionsParallel on many short
+// segments, which suit slab partitioning, for increasing numbers of workers.
+func BenchmarkParallel(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	segments := make([]benott.Segment, 100000)
+	for i := range segments {
+		x, y := rng.Float64()*1000, rng.Float64()*1000
+		segments[i] = benott.Segment{P1: benott.Point{X: x, Y: y}, P2: benott.Point{X: x + rng.Float64()*4 - 2, Y: y + rng.Float64()*4 - 2}}
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("Workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				benott.CountIntersectionsParallel(segments, workers)
+			}
+		})
+	}
+}
@@ -1,8 +1,16 @@
 package benott_test
 
 import (
+	"container/heap"
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -203,3 +211,1623 @@ func TestImplementationsAgainstRandomData(t *testing.T) {
 		})
 	}
 }
+
+func TestImplementationsAgainstDenseRandomData(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Long random segments cross each other about n*n/9 times, so most event
+	// points are computed intersections rather than input endpoints.
+	segments := make([]benott.Segment, 1000)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000},
+			P2: benott.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000},
+		}
+	}
+
+	expected := benott.CountIntersectionsNaive(segments)
+	if actual := benott.CountIntersections(segments); actual != expected {
+		t.Fatalf("Naive algorithm expected %d intersections, but Bentley-Ottmann found %d", expected, actual)
+	}
+}
+
+func TestEventQueueOrdersPointsExactly(t *testing.T) {
+	// These points are pairwise within the default tolerance in X or not, so
+	// an order treating close X-coordinates as equal is not transitive.
+	points := []benott.Point{{0, 2}, {5e-10, 1}, {1.5e-9, 0}, {5e-10, -1}, {0, 2}, {-1, 3}}
+	eq := &benott.EventQueue{}
+	for _, p := range points {
+		heap.Push(eq, &benott.Event{Point: p})
+	}
+
+	var popped []benott.Point
+	for eq.Len() > 0 {
+		popped = append(popped, heap.Pop(eq).(*benott.Event).Point)
+	}
+	expected := []benott.Point{{-1, 3}, {0, 2}, {0, 2}, {5e-10, -1}, {5e-10, 1}, {1.5e-9, 0}}
+	if !reflect.DeepEqual(popped, expected) {
+		t.Errorf("Expected events in the order %v, got %v", expected, popped)
+	}
+}
+
+func TestIntersectionsComputedWithRoundingErrors(t *testing.T) {
+	// On an integer grid, many segments cross at points with non-terminating
+	// binary coordinates, which each pair computes with its own rounding:
+	// crossings at x = 37/3 land on either side of the nearest float64. Such
+	// events must still be merged with the others at the same point.
+	for seed := int64(0); seed < 20; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		segments := make([]benott.Segment, 200)
+		for i := range segments {
+			segments[i] = benott.Segment{
+				P1: benott.Point{X: float64(rng.Intn(20)), Y: float64(rng.Intn(20))},
+				P2: benott.Point{X: float64(rng.Intn(20)), Y: float64(rng.Intn(20))},
+			}
+		}
+
+		expected := benott.IntersectionStatsWithOptions(segments, benott.Options{Robust: true})
+		actual := benott.IntersectionStats(segments)
+		if naive := benott.CountIntersectionsNaive(segments); actual.Pairs != naive || actual.Points != expected.Points {
+			t.Errorf("Seed %d: expected %d pairs at %d points, got %d pairs at %d points", seed, naive, expected.Points, actual.Pairs, actual.Points)
+		}
+	}
+}
+
+func TestSteepSegmentCrossingsInOneColumn(t *testing.T) {
+	// The other two segments cross each other, then the steep third one
+	// crosses both, all less than the tolerance apart in X, in one column,
+	// but farther apart in Y. Its crossing with the second segment is the
+	// lower one, and is reached while the first still lies between them.
+	// Taken from dense random data, where the steep segment left in the wrong
+	// place then hid over ten thousand crossings.
+	segments := []benott.Segment{
+		{P1: benott.Point{985.4683864979339, 379.22142870604074}, P2: benott.Point{293.3859386929604, 715.798785341207}},
+		{P1: benott.Point{829.8942124497166, 267.18060289205744}, P2: benott.Point{445.13542339230196, 811.0439049623169}},
+		{P1: benott.Point{628.3134588628193, 284.86045590233107}, P2: benott.Point{626.6411029290994, 807.5458925541055}},
+	}
+	if naive, actual := benott.CountIntersectionsNaive(segments), benott.CountIntersections(segments); naive != 3 || actual != 3 {
+		t.Errorf("Expected 3 intersections, naive got %d, Bentley-Ottmann got %d", naive, actual)
+	}
+}
+
+func TestNearlyParallelSegmentsCountedOnce(t *testing.T) {
+	// The first two segments are nearly parallel and stay within the
+	// tolerance of each other on both sides of where they cross, so they
+	// also pass through the point just past it where the third crosses both.
+	// Taken from dense random data.
+	segments := []benott.Segment{
+		{P1: benott.Point{839.8475451970205, 833.2555860440903}, P2: benott.Point{321.2447075758867, 118.36223968233608}},
+		{P1: benott.Point{288.98570784190974, 55.25812202260274}, P2: benott.Point{838.5467412854881, 856.4127402468289}},
+		{P1: benott.Point{565.0656078847212, 359.8276058279185}, P2: benott.Point{456.52961817168745, 460.05956214319394}},
+	}
+	expected := [][2]int{{0, 1}, {0, 2}, {1, 2}}
+	if actual := benott.IntersectingPairs(segments); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected pairs %v, got %v", expected, actual)
+	}
+}
+
+// --- Intersection Reporting ---
+
+func TestFindIntersectionsReportsPointsAndSegments(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{10, 0}, P2: benott.Point{0, 10}}, // Reversed endpoints are normalized internally.
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{0, 8}, P2: benott.Point{10, 8}},
+	}
+	expected := []benott.IntersectionResult{
+		{Point: benott.Point{2, 8}, Segments: []int{1, 3}},
+		{Point: benott.Point{5, 5}, Segments: []int{0, 1, 2}},
+		{Point: benott.Point{5, 8}, Segments: []int{2, 3}},
+		{Point: benott.Point{8, 8}, Segments: []int{0, 3}},
+	}
+
+	actual := benott.FindIntersections(segments)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestFindIntersectionsEmpty(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{5, 5}},
+		{P1: benott.Point{10, 0}, P2: benott.Point{5, 5}},
+	}
+	if actual := benott.FindIntersections(segments); len(actual) != 0 {
+		t.Errorf("Expected no intersections, got %v", actual)
+	}
+}
+
+func TestFindIntersectionsMatchesCount(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	segments := make([]benott.Segment, 200)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000},
+			P2: benott.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000},
+		}
+	}
+
+	// Random segments are in general position, so every point is a single pair.
+	results := benott.FindIntersections(segments)
+	expected := benott.CountIntersections(segments)
+	if len(results) != expected {
+		t.Fatalf("Expected %d intersection points, got %d", expected, len(results))
+	}
+	for _, result := range results {
+		if len(result.Segments) != 2 {
+			t.Errorf("Expected a pair of segments at %v, got %v", result.Point, result.Segments)
+		}
+	}
+}
+
+func TestIntersectingPairs(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 8}, P2: benott.Point{10, 8}},
+		{P1: benott.Point{10, 10}, P2: benott.Point{0, 0}}, // Reversed endpoints keep their index.
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{20, 0}, P2: benott.Point{30, 0}}, // Isolated.
+	}
+	expected := [][2]int{{0, 1}, {0, 2}, {0, 3}, {1, 2}, {1, 3}, {2, 3}}
+
+	actual := benott.IntersectingPairs(segments)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestIntersectingPairsWithOptions(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{5, 5}},  // Ends on segment 0.
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 5}},   // Ends there too.
+		{P1: benott.Point{6, 6}, P2: benott.Point{12, 12}}, // Overlaps segment 0.
+	}
+	tests := []struct {
+		opts     benott.Options
+		expected [][2]int
+	}{
+		{benott.Options{}, [][2]int{{0, 1}, {0, 2}}},
+		{benott.Options{Endpoints: benott.IncludeSharedEndpoints}, [][2]int{{0, 1}, {0, 2}, {1, 2}}},
+		{benott.Options{Endpoints: benott.ProperCrossingsOnly}, nil},
+		{benott.Options{ReportOverlaps: true}, [][2]int{{0, 1}, {0, 2}, {0, 3}}},
+	}
+	for _, tt := range tests {
+		if actual := benott.IntersectingPairsWithOptions(segments, tt.opts); !reflect.DeepEqual(actual, tt.expected) {
+			t.Errorf("With %+v, expected %v, got %v", tt.opts, tt.expected, actual)
+		}
+	}
+}
+
+func TestIntersectingPairsMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	segments := make([]benott.Segment, 100)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000},
+			P2: benott.Point{X: rng.Float64() * 1000, Y: rng.Float64() * 1000},
+		}
+	}
+
+	var expected [][2]int
+	for i := range segments {
+		for j := i + 1; j < len(segments); j++ {
+			if benott.CountIntersectionsNaive([]benott.Segment{segments[i], segments[j]}) == 1 {
+				expected = append(expected, [2]int{i, j})
+			}
+		}
+	}
+
+	actual := benott.IntersectingPairs(segments)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Pairs differ from the naive method: expected %d pairs, got %d", len(expected), len(actual))
+	}
+}
+
+func TestIntersectionsIterator(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+		{P1: benott.Point{0, 6}, P2: benott.Point{10, 6}},
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{6, 0}, P2: benott.Point{6, 10}},
+	}
+
+	var all []benott.IntersectionResult
+	for result := range benott.Intersections(segments) {
+		all = append(all, result)
+	}
+	if expected := benott.FindIntersections(segments); !reflect.DeepEqual(all, expected) {
+		t.Fatalf("Expected %v, got %v", expected, all)
+	}
+}
+
+func TestIntersectionsIteratorStopsEarly(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+		{P1: benott.Point{0, 6}, P2: benott.Point{10, 6}},
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{6, 0}, P2: benott.Point{6, 10}},
+	}
+
+	seen := 0
+	for result := range benott.Intersections(segments) {
+		seen++
+		if result.Point != (benott.Point{5, 5}) {
+			t.Errorf("Expected the leftmost, lowest intersection first, got %v", result.Point)
+		}
+		break
+	}
+	if seen != 1 {
+		t.Errorf("Expected the iterator to stop after 1 result, got %d", seen)
+	}
+}
+
+// --- Early-Exit Check ---
+
+func TestHasIntersection(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{1, 1}},
+		{P1: benott.Point{20, 0}, P2: benott.Point{30, 10}},
+		{P1: benott.Point{30, 0}, P2: benott.Point{20, 10}},
+		{P1: benott.Point{40, 0}, P2: benott.Point{50, 10}},
+		{P1: benott.Point{40, 10}, P2: benott.Point{50, 0}},
+	}
+
+	found, pair := benott.HasIntersection(segments)
+	if !found {
+		t.Fatal("Expected an intersection to be found")
+	}
+	// The leftmost intersection is reported.
+	if pair != [2]int{1, 2} {
+		t.Errorf("Expected pair [1 2], got %v", pair)
+	}
+}
+
+func TestHasIntersectionNone(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{5, 5}},
+		{P1: benott.Point{10, 0}, P2: benott.Point{5, 5}}, // Shared endpoint only.
+		{P1: benott.Point{0, 1}, P2: benott.Point{10, 11}},
+	}
+	if found, pair := benott.HasIntersection(segments); found {
+		t.Errorf("Expected no intersection, got pair %v", pair)
+	}
+}
+
+func TestSharedEndpointFanTakesLinearTime(t *testing.T) {
+	// Segments fanning out of the origin to both sides meet only there, at
+	// their shared endpoint.
+	const n = 10000
+	segments := make([]benott.Segment, n)
+	for i := range segments {
+		sin, cos := math.Sincos(2 * math.Pi * (float64(i) + 0.5) / n)
+		segments[i] = benott.Segment{P1: benott.Point{0, 0}, P2: benott.Point{100 * cos, 100 * sin}}
+	}
+	if found, pair := benott.HasIntersection(segments); found {
+		t.Errorf("Expected no intersection, got pair %v", pair)
+	}
+
+	// The context is checked at a fixed interval of events and tested pairs,
+	// so it runs out long before all n*(n-1)/2 pairs at the origin are tested.
+	for _, opts := range []benott.Options{{}, {Endpoints: benott.ProperCrossingsOnly}, {Robust: true}} {
+		ctx := &cancelAfter{Context: context.Background(), checks: 4 * n / 256}
+		if count, err := benott.CountIntersectionsContext(ctx, segments, opts); count != 0 || err != nil {
+			t.Errorf("Endpoints %d, robust %v: expected no intersections, got %d and %v", opts.Endpoints, opts.Robust, count, err)
+		}
+	}
+}
+
+// --- Options ---
+
+// scaled returns a copy of segments with every coordinate multiplied by factor.
+func scaled(segments []benott.Segment, factor float64) []benott.Segment {
+	result := make([]benott.Segment, len(segments))
+	for i, s := range segments {
+		result[i] = benott.Segment{
+			P1: benott.Point{X: s.P1.X * factor, Y: s.P1.Y * factor},
+			P2: benott.Point{X: s.P2.X * factor, Y: s.P2.Y * factor},
+		}
+	}
+	return result
+}
+
+func TestCountIntersectionsWithOptionsDefault(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+	}
+	if actual := benott.CountIntersectionsWithOptions(segments, benott.Options{}); actual != 3 {
+		t.Errorf("Expected 3 intersections with zero Options, got %d", actual)
+	}
+}
+
+func TestCountIntersectionsWithOptionsRelativeTolerance(t *testing.T) {
+	grid := []benott.Segment{
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+		{P1: benott.Point{0, 6}, P2: benott.Point{10, 6}},
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{6, 0}, P2: benott.Point{6, 10}},
+	}
+	opts := benott.Options{Tolerance: benott.RelativeTolerance}
+
+	for _, factor := range []float64{1e-12, 1e-3, 1, 1e6, 1e12} {
+		t.Run(fmt.Sprintf("Scale=%g", factor), func(t *testing.T) {
+			if actual := benott.CountIntersectionsWithOptions(scaled(grid, factor), opts); actual != 4 {
+				t.Errorf("Expected 4 intersections, got %d", actual)
+			}
+		})
+	}
+
+	// At this scale the default absolute tolerance merges all grid lines.
+	if actual := benott.CountIntersections(scaled(grid, 1e-12)); actual == 4 {
+		t.Errorf("Expected the absolute tolerance to fail on a tiny grid")
+	}
+}
+
+func TestCountIntersectionsWithOptionsEpsilon(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{5, 0.001}, P2: benott.Point{5, 10}}, // Stops just short of the first segment.
+	}
+	if actual := benott.CountIntersectionsWithOptions(segments, benott.Options{}); actual != 0 {
+		t.Errorf("Expected no intersection with the default tolerance, got %d", actual)
+	}
+	if actual := benott.CountIntersectionsWithOptions(segments, benott.Options{Epsilon: 0.01}); actual != 1 {
+		t.Errorf("Expected a touching intersection with a coarse tolerance, got %d", actual)
+	}
+}
+
+// --- Robust Predicates ---
+
+func TestRobustNearMisses(t *testing.T) {
+	horizontal := benott.Segment{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}}
+	testCases := []struct {
+		name     string
+		stem     benott.Segment
+		opts     benott.Options
+		expected int
+	}{
+		// The stem pokes through the horizontal segment by far less than the
+		// tolerance, so it is a proper crossing only when decided exactly.
+		{"Crossing", benott.Segment{P1: benott.Point{5, 0}, P2: benott.Point{5, 5 + 1e-12}}, benott.Options{Endpoints: benott.ProperCrossingsOnly}, 0},
+		{"CrossingRobust", benott.Segment{P1: benott.Point{5, 0}, P2: benott.Point{5, 5 + 1e-12}}, benott.Options{Endpoints: benott.ProperCrossingsOnly, Robust: true}, 1},
+		// The stem stops just short of the horizontal segment.
+		{"Gap", benott.Segment{P1: benott.Point{5, 0}, P2: benott.Point{5, 5 - 1e-12}}, benott.Options{}, 1},
+		{"GapRobust", benott.Segment{P1: benott.Point{5, 0}, P2: benott.Point{5, 5 - 1e-12}}, benott.Options{Robust: true}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			segments := []benott.Segment{horizontal, tc.stem}
+			naive := benott.CountIntersectionsNaiveWithOptions(segments, tc.opts)
+			sweep := benott.CountIntersectionsWithOptions(segments, tc.opts)
+			if naive != tc.expected || sweep != tc.expected {
+				t.Errorf("Expected %d, naive got %d, Bentley-Ottmann got %d", tc.expected, naive, sweep)
+			}
+		})
+	}
+}
+
+func TestRobustAgainstScaledGridData(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Grid points scaled by a factor that is not a power of two are rounded,
+	// which produces nearly collinear segments and near misses that the
+	// tolerance-based predicates cannot tell apart.
+	for _, factor := range []float64{0.1, 1.0 / 3, 0.7} {
+		segments := make([]benott.Segment, 150)
+		for i := range segments {
+			segments[i] = benott.Segment{
+				P1: benott.Point{X: float64(rng.Intn(10)), Y: float64(rng.Intn(10))},
+				P2: benott.Point{X: float64(rng.Intn(10)), Y: float64(rng.Intn(10))},
+			}
+		}
+		segments = scaled(segments, factor)
+
+		for _, policy := range []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints} {
+			opts := benott.Options{Endpoints: policy, Robust: true}
+			expected := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+			actual := benott.CountIntersectionsWithOptions(segments, opts)
+			if actual != expected {
+				t.Errorf("Scale %g, policy %d: naive algorithm expected %d intersections, but Bentley-Ottmann found %d", factor, policy, expected, actual)
+			}
+		}
+	}
+}
+
+func TestRobustAtExtremeMagnitudes(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Products of coordinates this large overflow float64, and products of
+	// coordinates this small underflow, so exact predicates cannot rely on
+	// float64 products.
+	for _, factor := range []float64{3e160, -3e160, 3e-160, -3e-160, 1e-150} {
+		cross := scaled([]benott.Segment{
+			{P1: benott.Point{0, 0}, P2: benott.Point{1, 1}},
+			{P1: benott.Point{0, 1}, P2: benott.Point{1, 0}},
+		}, factor)
+		opts := benott.Options{Robust: true}
+		if naive, sweep := benott.CountIntersectionsNaiveWithOptions(cross, opts), benott.CountIntersectionsWithOptions(cross, opts); naive != 1 || sweep != 1 {
+			t.Errorf("Scale %g: expected 1 intersection, naive got %d, Bentley-Ottmann got %d", factor, naive, sweep)
+		}
+
+		segments := make([]benott.Segment, 40)
+		for i := range segments {
+			segments[i] = benott.Segment{
+				P1: benott.Point{X: float64(rng.Intn(10)), Y: float64(rng.Intn(10))},
+				P2: benott.Point{X: float64(rng.Intn(10)), Y: float64(rng.Intn(10))},
+			}
+		}
+		segments = scaled(segments, factor)
+		for _, policy := range []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints} {
+			opts := benott.Options{Endpoints: policy, ReportOverlaps: true, Robust: true}
+			expected := benott.CountIntersectionsRatWithOptions(ratSegments(segments), opts)
+			naive := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+			sweep := benott.CountIntersectionsWithOptions(segments, opts)
+			if naive != expected || sweep != expected {
+				t.Errorf("Scale %g, policy %d: expected %d intersections, naive got %d, Bentley-Ottmann got %d", factor, policy, expected, naive, sweep)
+			}
+		}
+	}
+}
+
+func TestRobustNearlyCoincidentEvents(t *testing.T) {
+	// The segments cross about 4e-11 to the right of the lower end of the
+	// first one, closer than the tolerance, and only an exact sweep keeps
+	// the two events apart.
+	segments := []benott.Segment{
+		{P1: benott.Point{1.9999999999918927, 1.9999999999897125}, P2: benott.Point{1.9999999999583538, 8.000000000043404}},
+		{P1: benott.Point{2.0000000000259748, 3.0000000000375806}, P2: benott.Point{0.9999999999503918, 1.0000000000018006}},
+	}
+	opts := benott.Options{Robust: true}
+	naive := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+	sweep := benott.CountIntersectionsWithOptions(segments, opts)
+	exact := benott.CountIntersectionsRat(ratSegments(segments))
+	if naive != 1 || sweep != 1 || exact != 1 {
+		t.Errorf("Expected 1 intersection, naive got %d, Bentley-Ottmann got %d, the rational sweep got %d", naive, sweep, exact)
+	}
+}
+
+func TestRobustNearlyConcurrentSegments(t *testing.T) {
+	// The first segment passes within the tolerance of the point where the
+	// other two cross, but misses it. The tolerance-based algorithms apply
+	// the tolerance to different pairs and disagree; robust ones do not.
+	segments := []benott.Segment{
+		{P1: benott.Point{2.999999999956057, 2.0000000000213984}, P2: benott.Point{2.999999999951839, 3.0000000000395497}},
+		{P1: benott.Point{5.000000000001727, 2.451197536622548e-11}, P2: benott.Point{3.000000000002972, 2.0000000000364375}},
+		{P1: benott.Point{4.00000000003352, 1.0000000000309068}, P2: benott.Point{2.9999999999775886, 4.000000000018503}},
+	}
+	opts := benott.Options{Robust: true}
+	naive := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+	sweep := benott.CountIntersectionsWithOptions(segments, opts)
+	exact := benott.CountIntersectionsRat(ratSegments(segments))
+	if naive != exact || sweep != exact {
+		t.Errorf("Expected %d intersections, naive got %d, Bentley-Ottmann got %d", exact, naive, sweep)
+	}
+}
+
+func TestRobustAgainstPerturbedGridData(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Moving some grid points by less than 1e-9 leaves exact coincidences
+	// next to events and crossings far closer together than the tolerance.
+	perturb := func(v int) float64 {
+		if rng.Intn(2) == 0 {
+			return float64(v)
+		}
+		return float64(v) + (rng.Float64()*2-1)*1e-9
+	}
+	for range 10 {
+		segments := make([]benott.Segment, 40)
+		for i := range segments {
+			segments[i] = benott.Segment{
+				P1: benott.Point{X: perturb(rng.Intn(6)), Y: perturb(rng.Intn(6))},
+				P2: benott.Point{X: perturb(rng.Intn(6)), Y: perturb(rng.Intn(6))},
+			}
+		}
+
+		for _, policy := range []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints} {
+			opts := benott.Options{Endpoints: policy, ReportOverlaps: true, Robust: true}
+			expected := benott.CountIntersectionsRatWithOptions(ratSegments(segments), opts)
+			naive := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+			sweep := benott.CountIntersectionsWithOptions(segments, opts)
+			if naive != expected || sweep != expected {
+				t.Errorf("Policy %d: the rational sweep expected %d intersections, naive got %d, Bentley-Ottmann got %d", policy, expected, naive, sweep)
+			}
+		}
+	}
+}
+
+// --- Endpoint Policies ---
+
+func TestEndpointPolicies(t *testing.T) {
+	cross := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+	}
+	tJunction := []benott.Segment{
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{5, 5}, P2: benott.Point{10, 5}}, // Starts on the vertical segment.
+	}
+	vShape := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{5, 5}},
+		{P1: benott.Point{10, 0}, P2: benott.Point{5, 5}},
+	}
+	collinear := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{5, 5}},
+		{P1: benott.Point{5, 5}, P2: benott.Point{10, 10}},
+	}
+
+	testCases := []struct {
+		name     string
+		policy   benott.EndpointPolicy
+		expected [4]int // cross, tJunction, vShape, collinear
+	}{
+		{"ProperCrossingsOnly", benott.ProperCrossingsOnly, [4]int{1, 0, 0, 0}},
+		{"IncludeTJunctions", benott.IncludeTJunctions, [4]int{1, 1, 0, 0}},
+		{"IncludeSharedEndpoints", benott.IncludeSharedEndpoints, [4]int{1, 1, 1, 0}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := benott.Options{Endpoints: tc.policy}
+			for i, segments := range [][]benott.Segment{cross, tJunction, vShape, collinear} {
+				naive := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+				sweep := benott.CountIntersectionsWithOptions(segments, opts)
+				if naive != tc.expected[i] || sweep != tc.expected[i] {
+					t.Errorf("Case %d: expected %d, naive got %d, Bentley-Ottmann got %d", i, tc.expected[i], naive, sweep)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointPoliciesAgainstGridData(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Integer coordinates on a small grid produce many shared endpoints,
+	// T-junctions and points where several segments meet.
+	segments := make([]benott.Segment, 200)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: float64(rng.Intn(20)), Y: float64(rng.Intn(20))},
+			P2: benott.Point{X: float64(rng.Intn(20)), Y: float64(rng.Intn(20))},
+		}
+	}
+
+	for _, policy := range []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints} {
+		opts := benott.Options{Endpoints: policy}
+		expected := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+		actual := benott.CountIntersectionsWithOptions(segments, opts)
+		if actual != expected {
+			t.Errorf("Policy %d: naive algorithm expected %d intersections, but Bentley-Ottmann found %d", policy, expected, actual)
+		}
+	}
+}
+
+// --- Collinear Overlaps ---
+
+func TestCollinearOverlapsReported(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{8, 8}, P2: benott.Point{2, 2}},
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 4}},
+		{P1: benott.Point{5, 2}, P2: benott.Point{5, 12}},    // Overlaps the vertical segment above and crosses the diagonals.
+		{P1: benott.Point{10, 10}, P2: benott.Point{12, 12}}, // Touches segment 0 end to end only.
+	}
+	opts := benott.Options{ReportOverlaps: true}
+	expected := []benott.IntersectionResult{
+		{Kind: benott.CollinearOverlap, Point: benott.Point{2, 2}, Segments: []int{0, 1}, Overlap: [2]benott.Point{{2, 2}, {8, 8}}},
+		{Kind: benott.CollinearOverlap, Point: benott.Point{5, 2}, Segments: []int{2, 3}, Overlap: [2]benott.Point{{5, 2}, {5, 4}}},
+		{Kind: benott.PointIntersection, Point: benott.Point{5, 5}, Segments: []int{0, 1, 3}},
+	}
+
+	actual := benott.FindIntersectionsWithOptions(segments, opts)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("Expected %v, got %v", expected, actual)
+	}
+
+	// Two crossings and two overlaps.
+	if count := benott.CountIntersectionsWithOptions(segments, opts); count != 4 {
+		t.Errorf("Expected 4 intersections, got %d", count)
+	}
+	if count := benott.CountIntersectionsNaiveWithOptions(segments, opts); count != 4 {
+		t.Errorf("Expected 4 intersections from the naive method, got %d", count)
+	}
+}
+
+// Rounding leaves these segments exactly parallel but on distinct lines,
+// within the tolerance of each other.
+func TestRobustParallelSegmentsDoNotOverlap(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0.2, 0.5}, P2: benott.Point{0.5, 0.2}},
+		{P1: benott.Point{0.4, 0.30000000000000004}, P2: benott.Point{0.30000000000000004, 0.4}},
+	}
+	opts := benott.Options{Robust: true, ReportOverlaps: true}
+	if count := benott.CountIntersectionsWithOptions(segments, opts); count != 0 {
+		t.Errorf("Expected no intersections, got %d", count)
+	}
+}
+
+func TestCollinearOverlapsIgnoredByDefault(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{2, 2}, P2: benott.Point{8, 8}},
+	}
+	if actual := benott.FindIntersections(segments); len(actual) != 0 {
+		t.Errorf("Expected no results without ReportOverlaps, got %v", actual)
+	}
+}
+
+// --- Status Reordering ---
+
+// A pencil of lines through the origin, with duplicated and nearly passing
+// lines, is reversed at the origin; a second pencil further right crosses
+// every line after that, which is only found if the new order is right.
+func TestPencilsReorderedAtTheirCenter(t *testing.T) {
+	var segments []benott.Segment
+	for _, slope := range []float64{-3, -1, -1, -0.5, 0, 0.25, 1, 1, 1, 4} {
+		segments = append(segments, benott.Segment{P1: benott.Point{-4, -4 * slope}, P2: benott.Point{4, 4 * slope}})
+	}
+	segments = append(segments,
+		benott.Segment{P1: benott.Point{-4, -2 + 1e-10}, P2: benott.Point{4, 2 + 1e-10}}, // Passes the origin within the tolerance.
+		benott.Segment{P1: benott.Point{-4, -4}, P2: benott.Point{-4, 4}},
+		benott.Segment{P1: benott.Point{0, -10}, P2: benott.Point{0, 10}},
+		benott.Segment{P1: benott.Point{2, -20}, P2: benott.Point{3, 20}},
+		benott.Segment{P1: benott.Point{2, 20}, P2: benott.Point{3, -20}},
+	)
+
+	for _, opts := range []benott.Options{{}, {ReportOverlaps: true}, {Robust: true}, {Robust: true, ReportOverlaps: true}} {
+		expected := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+		if actual := benott.CountIntersectionsWithOptions(segments, opts); actual != expected {
+			t.Errorf("%+v: naive algorithm expected %d intersections, but Bentley-Ottmann found %d", opts, expected, actual)
+		}
+	}
+
+	ints := make([]benott.IntSegment, 10)
+	for i, slope := range []int64{-3, -1, -1, 0, 0, 1, 1, 1, 2, 4} {
+		ints[i] = benott.IntSegment{P1: benott.IntPoint{-4, -4 * slope}, P2: benott.IntPoint{4, 4 * slope}}
+	}
+	ints = append(ints, benott.IntSegment{P1: benott.IntPoint{2, -20}, P2: benott.IntPoint{3, 20}})
+	// The ten lines meet at the origin in every pair but the five collinear
+	// ones, and the last segment crosses each of them once more.
+	if actual := benott.CountIntersectionsInt(ints); actual != 45-5+10 {
+		t.Errorf("Expected %d intersections, got %d", 45-5+10, actual)
+	}
+}
+
+// --- Statistics ---
+
+func TestIntersectionStats(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},  // Vertical
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},  // Horizontal
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}}, // Diagonal 1
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}}, // Diagonal 2
+		{P1: benott.Point{0, 8}, P2: benott.Point{10, 8}},  // Crosses the other three
+	}
+	// Four segments meet at (5,5); the last one crosses three of them elsewhere.
+	expected := benott.Stats{Points: 4, Pairs: 9, MaxMultiplicity: 4}
+
+	actual := benott.IntersectionStats(segments)
+	if actual != expected {
+		t.Errorf("Expected %+v, got %+v", expected, actual)
+	}
+	if count := benott.CountIntersections(segments); count != actual.Pairs {
+		t.Errorf("Expected Pairs to match CountIntersections (%d), got %d", count, actual.Pairs)
+	}
+}
+
+func TestIntersectionStatsEmpty(t *testing.T) {
+	if actual := benott.IntersectionStats(nil); actual != (benott.Stats{}) {
+		t.Errorf("Expected zero Stats, got %+v", actual)
+	}
+}
+
+// --- Integer Coordinates ---
+
+func TestCountIntersectionsInt(t *testing.T) {
+	// At this magnitude float64 cannot tell y = 2^60 from y = 2^60+1, so the
+	// floating-point sweep sees the stem touching the horizontal segment.
+	const huge = 1 << 60
+	horizontal := benott.IntSegment{P1: benott.IntPoint{-huge, huge + 1}, P2: benott.IntPoint{huge, huge + 1}}
+	testCases := []struct {
+		name     string
+		stem     benott.IntSegment
+		expected int
+	}{
+		{"Gap", benott.IntSegment{P1: benott.IntPoint{0, 0}, P2: benott.IntPoint{0, huge}}, 0},
+		{"TJunction", benott.IntSegment{P1: benott.IntPoint{0, 0}, P2: benott.IntPoint{0, huge + 1}}, 1},
+		{"Crossing", benott.IntSegment{P1: benott.IntPoint{-1, 0}, P2: benott.IntPoint{1, huge + 2}}, 1},
+		{"Parallel", benott.IntSegment{P1: benott.IntPoint{-huge, huge}, P2: benott.IntPoint{huge, huge}}, 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := benott.CountIntersectionsInt([]benott.IntSegment{horizontal, tc.stem}); actual != tc.expected {
+				t.Errorf("Expected %d intersections, got %d", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestCountIntersectionsIntAgainstNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Small integers are exact in float64, so the robust naive method gives the
+	// exact answer to compare against.
+	ints := make([]benott.IntSegment, 100)
+	floats := make([]benott.Segment, len(ints))
+	for i := range ints {
+		ints[i] = benott.IntSegment{
+			P1: benott.IntPoint{X: rng.Int63n(15), Y: rng.Int63n(15)},
+			P2: benott.IntPoint{X: rng.Int63n(15), Y: rng.Int63n(15)},
+		}
+		floats[i] = benott.Segment{
+			P1: benott.Point{X: float64(ints[i].P1.X), Y: float64(ints[i].P1.Y)},
+			P2: benott.Point{X: float64(ints[i].P2.X), Y: float64(ints[i].P2.Y)},
+		}
+	}
+
+	for _, policy := range []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints} {
+		opts := benott.Options{Endpoints: policy, ReportOverlaps: true, Robust: true}
+		expected := benott.CountIntersectionsNaiveWithOptions(floats, opts)
+		actual := benott.CountIntersectionsIntWithOptions(ints, opts)
+		if actual != expected {
+			t.Errorf("Policy %d: naive algorithm expected %d intersections, but the integer sweep found %d", policy, expected, actual)
+		}
+	}
+}
+
+func TestFindIntersectionsInt(t *testing.T) {
+	const huge = 1 << 60
+	segments := []benott.IntSegment{
+		{P1: benott.IntPoint{0, 0}, P2: benott.IntPoint{3, 3}},
+		{P1: benott.IntPoint{0, 2}, P2: benott.IntPoint{2, 0}},
+		{P1: benott.IntPoint{-huge, 1}, P2: benott.IntPoint{huge, 2}},
+		{P1: benott.IntPoint{1, 1}, P2: benott.IntPoint{4, 4}}, // Overlaps the first one.
+	}
+	// The nearly horizontal segment crosses the others at points whose
+	// coordinates float64 cannot represent.
+	expected := []benott.RatIntersectionResult{
+		{Point: benott.RatPoint{rat(huge, 2*huge+1), rat(3*huge+2, 2*huge+1)}, Segments: []int{1, 2}},
+		{Point: benott.RatPoint{rat(1, 1), rat(1, 1)}, Segments: []int{0, 1, 3}},
+		{Kind: benott.CollinearOverlap, Point: benott.RatPoint{rat(1, 1), rat(1, 1)}, Segments: []int{0, 3}},
+		{Point: benott.RatPoint{rat(3*huge, 2*huge-1), rat(3*huge, 2*huge-1)}, Segments: []int{0, 2, 3}},
+	}
+
+	actual := benott.FindIntersectionsIntWithOptions(segments, benott.Options{ReportOverlaps: true})
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(actual))
+	}
+	for i, r := range actual {
+		e := expected[i]
+		if r.Kind != e.Kind || r.Point.X.Cmp(e.Point.X) != 0 || r.Point.Y.Cmp(e.Point.Y) != 0 || !reflect.DeepEqual(r.Segments, e.Segments) {
+			t.Errorf("Result %d: expected %v at (%v, %v), got %v at (%v, %v)", i, e.Segments, e.Point.X, e.Point.Y, r.Segments, r.Point.X, r.Point.Y)
+		}
+	}
+	if points := benott.FindIntersectionsInt(segments); len(points) != 3 {
+		t.Errorf("Expected 3 points without overlaps, got %d", len(points))
+	}
+}
+
+// --- Rational Coordinates ---
+
+// rat returns the rational number a/b.
+func rat(a, b int64) *big.Rat { return big.NewRat(a, b) }
+
+// ratSegments returns the exact values of segments with finite coordinates.
+func ratSegments(segments []benott.Segment) []benott.RatSegment {
+	rats := make([]benott.RatSegment, len(segments))
+	for i, s := range segments {
+		rats[i] = benott.RatSegment{
+			P1: benott.RatPoint{X: new(big.Rat).SetFloat64(s.P1.X), Y: new(big.Rat).SetFloat64(s.P1.Y)},
+			P2: benott.RatPoint{X: new(big.Rat).SetFloat64(s.P2.X), Y: new(big.Rat).SetFloat64(s.P2.Y)},
+		}
+	}
+	return rats
+}
+
+func TestFindIntersectionsRat(t *testing.T) {
+	segments := []benott.RatSegment{
+		{P1: benott.RatPoint{rat(0, 1), rat(0, 1)}, P2: benott.RatPoint{rat(1, 1), rat(1, 1)}},
+		{P1: benott.RatPoint{rat(0, 1), rat(2, 3)}, P2: benott.RatPoint{rat(2, 3), rat(0, 1)}},
+		{P1: benott.RatPoint{rat(1, 3), rat(0, 1)}, P2: benott.RatPoint{rat(1, 3), rat(1, 1)}},
+		{P1: benott.RatPoint{rat(0, 1), rat(1, 7)}, P2: benott.RatPoint{rat(1, 1), rat(1, 7)}},
+	}
+	// Three segments meet exactly at (1/3, 1/3); the last one crosses all three.
+	expected := []benott.RatIntersectionResult{
+		{Point: benott.RatPoint{rat(1, 7), rat(1, 7)}, Segments: []int{0, 3}},
+		{Point: benott.RatPoint{rat(1, 3), rat(1, 7)}, Segments: []int{2, 3}},
+		{Point: benott.RatPoint{rat(1, 3), rat(1, 3)}, Segments: []int{0, 1, 2}},
+		{Point: benott.RatPoint{rat(11, 21), rat(1, 7)}, Segments: []int{1, 3}},
+	}
+
+	actual := benott.FindIntersectionsRat(segments)
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(actual))
+	}
+	for i, r := range actual {
+		e := expected[i]
+		if r.Kind != e.Kind || r.Point.X.Cmp(e.Point.X) != 0 || r.Point.Y.Cmp(e.Point.Y) != 0 || !reflect.DeepEqual(r.Segments, e.Segments) {
+			t.Errorf("Result %d: expected %v at (%v, %v), got %v at (%v, %v)", i, e.Segments, e.Point.X, e.Point.Y, r.Segments, r.Point.X, r.Point.Y)
+		}
+	}
+	if count := benott.CountIntersectionsRat(segments); count != 6 {
+		t.Errorf("Expected 6 intersections, got %d", count)
+	}
+}
+
+func TestCountIntersectionsRatAgainstNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	// Every float64 is a rational, so the robust naive method on the same
+	// points gives the exact answer to compare against.
+	floats := make([]benott.Segment, 100)
+	for i := range floats {
+		floats[i] = benott.Segment{
+			P1: benott.Point{X: float64(rng.Intn(15)) / 4, Y: float64(rng.Intn(15)) / 4},
+			P2: benott.Point{X: float64(rng.Intn(15)) / 4, Y: float64(rng.Intn(15)) / 4},
+		}
+	}
+	rats := ratSegments(floats)
+
+	for _, policy := range []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints} {
+		opts := benott.Options{Endpoints: policy, ReportOverlaps: true, Robust: true}
+		expected := benott.CountIntersectionsNaiveWithOptions(floats, opts)
+		actual := benott.CountIntersectionsRatWithOptions(rats, opts)
+		if actual != expected {
+			t.Errorf("Policy %d: naive algorithm expected %d intersections, but the rational sweep found %d", policy, expected, actual)
+		}
+	}
+}
+
+// --- Generic Payloads ---
+
+type road struct {
+	name string
+	path benott.Segment
+}
+
+func roadPath(r road) benott.Segment { return r.path }
+
+func TestFindIntersectionsOf(t *testing.T) {
+	roads := []road{
+		{"Main St", benott.Segment{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}}},
+		{"1st Ave", benott.Segment{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}}},
+		{"Diagonal", benott.Segment{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}}},
+		{"Side St", benott.Segment{P1: benott.Point{0, 8}, P2: benott.Point{2, 8}}},
+	}
+	expected := []benott.IntersectionOf[road]{
+		{Point: benott.Point{5, 5}, Items: []road{roads[0], roads[1], roads[2]}},
+	}
+
+	actual := benott.FindIntersectionsOf(roads, roadPath)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestIntersectingPairsOf(t *testing.T) {
+	ids := []int{101, 202, 303}
+	paths := map[int]benott.Segment{
+		101: {P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		202: {P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		303: {P1: benott.Point{20, 0}, P2: benott.Point{20, 10}},
+	}
+	expected := [][2]int{{101, 202}}
+
+	actual := benott.IntersectingPairsOf(ids, func(id int) benott.Segment { return paths[id] })
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestIntersectingPairsOfWithOptions(t *testing.T) {
+	roads := []road{
+		{"Main St", benott.Segment{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}}},
+		{"Side St", benott.Segment{P1: benott.Point{10, 5}, P2: benott.Point{10, 10}}}, // Starts where Main St ends.
+		{"1st Ave", benott.Segment{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}}},
+	}
+	testCases := []struct {
+		endpoints benott.EndpointPolicy
+		expected  [][2]road
+	}{
+		{benott.IncludeTJunctions, [][2]road{{roads[0], roads[2]}}},
+		{benott.IncludeSharedEndpoints, [][2]road{{roads[0], roads[1]}, {roads[0], roads[2]}}},
+	}
+
+	for _, tc := range testCases {
+		actual := benott.IntersectingPairsOfWithOptions(roads, roadPath, benott.Options{Endpoints: tc.endpoints})
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("Policy %d: expected %v, got %v", tc.endpoints, tc.expected, actual)
+		}
+	}
+}
+
+// --- Validation ---
+
+func TestCountIntersectionsCheckedNonFinite(t *testing.T) {
+	for _, bad := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		segments := []benott.Segment{
+			{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+			{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+			{P1: benott.Point{5, 0}, P2: benott.Point{5, bad}},
+		}
+		count, err := benott.CountIntersectionsChecked(segments, benott.Options{})
+		if !errors.Is(err, benott.ErrNonFinite) {
+			t.Fatalf("Coordinate %v: expected ErrNonFinite, got %v", bad, err)
+		}
+		var segErr *benott.SegmentError
+		if !errors.As(err, &segErr) || segErr.Index != 2 {
+			t.Errorf("Coordinate %v: expected the error to name segment 2, got %v", bad, err)
+		}
+		if count != 0 {
+			t.Errorf("Coordinate %v: expected a zero count with an error, got %d", bad, count)
+		}
+	}
+}
+
+func TestNonFiniteSegmentsLeftOut(t *testing.T) {
+	ctx := context.Background()
+	entryPoints := map[string]func([]benott.Segment, benott.Options) int{
+		"CountIntersections": benott.CountIntersectionsWithOptions,
+		"Naive":              benott.CountIntersectionsNaiveWithOptions,
+		"IntersectionStats": func(s []benott.Segment, opts benott.Options) int {
+			return benott.IntersectionStatsWithOptions(s, opts).Pairs
+		},
+		"FindIntersections": func(s []benott.Segment, opts benott.Options) int {
+			return len(benott.FindIntersectionsWithOptions(s, opts))
+		},
+		"Intersections": func(s []benott.Segment, opts benott.Options) int {
+			count := 0
+			for range benott.IntersectionsWithOptions(s, opts) {
+				count++
+			}
+			return count
+		},
+		"IntersectingPairs": func(s []benott.Segment, opts benott.Options) int {
+			return len(benott.IntersectingPairsWithOptions(s, opts))
+		},
+		"HasIntersection": func(s []benott.Segment, _ benott.Options) int {
+			if ok, pair := benott.HasIntersection(s); !ok || pair != [2]int{0, 1} {
+				return 0
+			}
+			return 1
+		},
+		"Parallel": func(s []benott.Segment, opts benott.Options) int {
+			return benott.CountIntersectionsParallelWithOptions(s, 4, opts)
+		},
+		"Sweeper": func(s []benott.Segment, opts benott.Options) int {
+			return benott.NewSweeper(opts).CountIntersections(s)
+		},
+		"Context": func(s []benott.Segment, opts benott.Options) int {
+			count, _ := benott.CountIntersectionsContext(ctx, s, opts)
+			return count
+		},
+		"RedBlue": func(s []benott.Segment, opts benott.Options) int {
+			return benott.CountRedBlueIntersectionsWithOptions(s[:1], s[1:], opts)
+		},
+		"ByLayer": func(s []benott.Segment, opts benott.Options) int {
+			return benott.CountIntersectionsByLayerWithOptions([][]benott.Segment{s[:1], s[1:]}, opts)[0][1]
+		},
+		"IntersectingPairsOf": func(s []benott.Segment, opts benott.Options) int {
+			return len(benott.IntersectingPairsOfWithOptions(s, func(s benott.Segment) benott.Segment { return s }, opts))
+		},
+	}
+	options := []benott.Options{
+		{},
+		{Tolerance: benott.RelativeTolerance},
+		{Endpoints: benott.IncludeSharedEndpoints, ReportOverlaps: true},
+		{Robust: true},
+	}
+
+	for _, bad := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		// Only the first two segments are finite, and they cross once.
+		segments := []benott.Segment{
+			{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+			{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+			{P1: benott.Point{5, 0}, P2: benott.Point{5, bad}},
+			{P1: benott.Point{bad, 2}, P2: benott.Point{8, 2}},
+			{P1: benott.Point{bad, bad}, P2: benott.Point{3, 3}},
+		}
+		for name, count := range entryPoints {
+			for i, opts := range options {
+				if actual := count(segments, opts); actual != 1 {
+					t.Errorf("%s with coordinate %v and options %d: expected 1 intersection, got %d", name, bad, i, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestZeroLengthPolicies(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{3, 5}, P2: benott.Point{3, 5}},
+	}
+
+	for _, policy := range []benott.ZeroLengthPolicy{benott.KeepZeroLength, benott.SkipZeroLength} {
+		count, err := benott.CountIntersectionsChecked(segments, benott.Options{ZeroLength: policy})
+		if err != nil || count != 1 {
+			t.Errorf("Policy %d: expected 1 intersection and no error, got %d and %v", policy, count, err)
+		}
+	}
+
+	_, err := benott.CountIntersectionsChecked(segments, benott.Options{ZeroLength: benott.RejectZeroLength})
+	var segErr *benott.SegmentError
+	if !errors.Is(err, benott.ErrZeroLength) || !errors.As(err, &segErr) || segErr.Index != 2 {
+		t.Errorf("Expected ErrZeroLength for segment 2, got %v", err)
+	}
+}
+
+func TestValidateAcceptsValidInput(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+	}
+	if err := benott.Validate(segments, benott.Options{ZeroLength: benott.RejectZeroLength}); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// --- Zero-Length Segments ---
+
+func TestZeroLengthSegmentsIntersect(t *testing.T) {
+	line := benott.Segment{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}}
+	marker := func(x, y float64) benott.Segment {
+		return benott.Segment{P1: benott.Point{x, y}, P2: benott.Point{x, y}}
+	}
+	testCases := []struct {
+		name     string
+		segments []benott.Segment
+		// expected holds the count for each policy: ProperCrossingsOnly,
+		// IncludeTJunctions, IncludeSharedEndpoints.
+		expected [3]int
+	}{
+		{"OnInterior", []benott.Segment{line, marker(4, 4)}, [3]int{1, 1, 1}},
+		{"OnEndpoint", []benott.Segment{line, marker(10, 10)}, [3]int{0, 1, 1}},
+		{"OffLine", []benott.Segment{line, marker(4, 5)}, [3]int{0, 0, 0}},
+		{"Coincident", []benott.Segment{marker(3, 3), marker(3, 3)}, [3]int{1, 1, 1}},
+		{"OnCrossing", []benott.Segment{line, {P1: benott.Point{0, 10}, P2: benott.Point{10, 0}}, marker(5, 5)}, [3]int{3, 3, 3}},
+	}
+
+	policies := []benott.EndpointPolicy{benott.ProperCrossingsOnly, benott.IncludeTJunctions, benott.IncludeSharedEndpoints}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, policy := range policies {
+				for _, robust := range []bool{false, true} {
+					opts := benott.Options{Endpoints: policy, Robust: robust}
+					naive := benott.CountIntersectionsNaiveWithOptions(tc.segments, opts)
+					sweep := benott.CountIntersectionsWithOptions(tc.segments, opts)
+					if naive != tc.expected[i] || sweep != tc.expected[i] {
+						t.Errorf("Policy %d, robust %v: expected %d, naive got %d, Bentley-Ottmann got %d", policy, robust, tc.expected[i], naive, sweep)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestZeroLengthSegmentsReported(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{4, 0}, P2: benott.Point{4, 0}},
+	}
+	expected := []benott.IntersectionResult{{Point: benott.Point{4, 0}, Segments: []int{0, 1}}}
+
+	if actual := benott.FindIntersections(segments); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+	if actual := benott.CountIntersectionsWithOptions(segments, benott.Options{ZeroLength: benott.SkipZeroLength}); actual != 0 {
+		t.Errorf("Expected skipped zero-length segments to intersect nothing, got %d", actual)
+	}
+}
+
+// --- Red-Blue Intersections ---
+
+func TestRedBlueIntersections(t *testing.T) {
+	roads := []benott.Segment{
+		{P1: benott.Point{0, 2}, P2: benott.Point{10, 2}},
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}}, // Crosses the other road at (2, 2).
+	}
+	rivers := []benott.Segment{
+		{P1: benott.Point{5, 0}, P2: benott.Point{5, 10}},
+		{P1: benott.Point{8, 0}, P2: benott.Point{8, 1}}, // Crosses nothing.
+	}
+	expected := []benott.RedBlueResult{
+		{Point: benott.Point{5, 2}, Red: []int{0}, Blue: []int{0}},
+		{Point: benott.Point{5, 5}, Red: []int{1}, Blue: []int{0}},
+	}
+
+	if actual := benott.FindRedBlueIntersections(roads, rivers); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+	if count := benott.CountRedBlueIntersections(roads, rivers); count != 2 {
+		t.Errorf("Expected 2 red-blue intersections, got %d", count)
+	}
+}
+
+func TestRedBlueIntersectionsAgainstRandomData(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	segments := make([]benott.Segment, 200)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: rng.Float64() * 100, Y: rng.Float64() * 100},
+			P2: benott.Point{X: rng.Float64() * 100, Y: rng.Float64() * 100},
+		}
+	}
+	red, blue := segments[:120], segments[120:]
+
+	// Count the pairs of the merged input that mix the two colours.
+	expected := 0
+	for _, pair := range benott.IntersectingPairs(segments) {
+		if (pair[0] < len(red)) != (pair[1] < len(red)) {
+			expected++
+		}
+	}
+
+	if actual := benott.CountRedBlueIntersections(red, blue); actual != expected {
+		t.Errorf("Expected %d red-blue intersections from the merged input, got %d", expected, actual)
+	}
+}
+
+// --- Layers ---
+
+func TestCountIntersectionsByLayer(t *testing.T) {
+	layers := [][]benott.Segment{
+		{ // Two crossing roads.
+			{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+			{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		},
+		{ // A river crossing both roads.
+			{P1: benott.Point{0, 2}, P2: benott.Point{10, 2}},
+		},
+		{ // A rail line crossing the river and one road.
+			{P1: benott.Point{1, 0}, P2: benott.Point{1, 5}},
+		},
+	}
+	expected := [][]int{
+		{1, 2, 1},
+		{2, 0, 1},
+		{1, 1, 0},
+	}
+
+	if actual := benott.CountIntersectionsByLayer(layers); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestCountIntersectionsByLayerAgainstRandomData(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	segments := make([]benott.Segment, 200)
+	layerOf := make([]int, len(segments))
+	layers := make([][]benott.Segment, 4)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: rng.Float64() * 100, Y: rng.Float64() * 100},
+			P2: benott.Point{X: rng.Float64() * 100, Y: rng.Float64() * 100},
+		}
+		layerOf[i] = rng.Intn(len(layers))
+	}
+	// Build the layers in input order so that the merged slice can be
+	// compared against them pair by pair.
+	merged := make([]benott.Segment, 0, len(segments))
+	mergedLayer := make([]int, 0, len(segments))
+	for l := range layers {
+		for i, s := range segments {
+			if layerOf[i] == l {
+				layers[l] = append(layers[l], s)
+				merged = append(merged, s)
+				mergedLayer = append(mergedLayer, l)
+			}
+		}
+	}
+
+	expected := make([][]int, len(layers))
+	for i := range expected {
+		expected[i] = make([]int, len(layers))
+	}
+	for _, pair := range benott.IntersectingPairs(merged) {
+		a, b := mergedLayer[pair[0]], mergedLayer[pair[1]]
+		expected[a][b]++
+		if a != b {
+			expected[b][a]++
+		}
+	}
+
+	if actual := benott.CountIntersectionsByLayer(layers); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expected %v, got %v", expected, actual)
+	}
+}
+
+// --- Sweeper ---
+
+func TestSweeperMatchesCountIntersections(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	sw := benott.NewSweeper(benott.Options{})
+
+	// Reuse a single Sweeper across inputs of varying size, including an
+	// empty one, and across a Reset to different Options.
+	for i, n := range []int{50, 200, 0, 10, 100} {
+		segments := make([]benott.Segment, n)
+		for j := range segments {
+			segments[j] = benott.Segment{
+				P1: benott.Point{X: float64(rng.Intn(20)), Y: float64(rng.Intn(20))},
+				P2: benott.Point{X: float64(rng.Intn(20)), Y: float64(rng.Intn(20))},
+			}
+		}
+		opts := benott.Options{Endpoints: benott.EndpointPolicy(i % 3), Robust: i%2 == 1}
+		sw.Reset(opts)
+
+		expected := benott.CountIntersectionsWithOptions(segments, opts)
+		for range 2 {
+			if actual := sw.CountIntersections(segments); actual != expected {
+				t.Errorf("Input %d: expected %d intersections, got %d", i, expected, actual)
+			}
+		}
+	}
+}
+
+func TestSweeperDoesNotAllocateAfterWarmUp(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	segments := make([]benott.Segment, 100)
+	for i := range segments {
+		segments[i] = benott.Segment{
+			P1: benott.Point{X: rng.Float64() * 100, Y: rng.Float64() * 100},
+			P2: benott.Point{X: rng.Float64() * 100, Y: rng.Float64() * 100},
+		}
+	}
+
+	// Robust sweeps compute with exact rationals, which allocate.
+	for _, opts := range []benott.Options{{}, {ReportOverlaps: true, Endpoints: benott.IncludeSharedEndpoints}} {
+		sw := benott.NewSweeper(opts)
+		sw.CountIntersections(segments)
+		if allocs := testing.AllocsPerRun(10, func() { sw.CountIntersections(segments) }); allocs != 0 {
+			t.Errorf("Options %+v: expected no allocations after warm-up, got %v per call", opts, allocs)
+		}
+	}
+}
+
+// --- Parallel Counting ---
+
+func TestCountIntersectionsParallelMatchesCountIntersections(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	short := make([]benott.Segment, 2000)
+	for i := range short {
+		x, y := rng.Float64()*100, rng.Float64()*100
+		short[i] = benott.Segment{P1: benott.Point{x, y}, P2: benott.Point{x + rng.Float64()*10 - 5, y + rng.Float64()*10 - 5}}
+	}
+	// Grid lines cross on the slab boundaries, which are placed at endpoint
+	// x-coordinates, and the vertical ones lie along them.
+	inputs := map[string][]benott.Segment{
+		"Short":  short,
+		"Random": generateRandomSegments(300, 100),
+		"Grid":   generateGridSegments(40, 100),
+		"Empty":  nil,
+	}
+
+	for name, segments := range inputs {
+		for _, opts := range []benott.Options{{}, {Endpoints: benott.IncludeSharedEndpoints, ReportOverlaps: true}, {Robust: true}} {
+			expected := benott.CountIntersectionsWithOptions(segments, opts)
+			for _, workers := range []int{0, 1, 2, 3, 8} {
+				if actual := benott.CountIntersectionsParallelWithOptions(segments, workers, opts); actual != expected {
+					t.Errorf("%s, %+v, %d workers: expected %d intersections, got %d", name, opts, workers, expected, actual)
+				}
+			}
+		}
+	}
+}
+
+func TestCountIntersectionsParallelOnSlabBoundaries(t *testing.T) {
+	// Every endpoint has x = 0 or x = 10, so slabs are split there, and the
+	// crossings and overlaps all lie on a boundary or span one.
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+		{P1: benott.Point{10, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 0}, P2: benott.Point{0, 10}},
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+	}
+	for _, opts := range []benott.Options{{}, {ReportOverlaps: true}, {Endpoints: benott.IncludeSharedEndpoints}} {
+		expected := benott.CountIntersectionsNaiveWithOptions(segments, opts)
+		for _, workers := range []int{2, 4} {
+			if actual := benott.CountIntersectionsParallelWithOptions(segments, workers, opts); actual != expected {
+				t.Errorf("%+v, %d workers: expected %d intersections, got %d", opts, workers, expected, actual)
+			}
+		}
+	}
+}
+
+// --- Cancellation and Budgets ---
+
+// cancelAfter is a context that reports itself canceled once Err has been
+// called more than checks times.
+type cancelAfter struct {
+	context.Context
+	checks int
+}
+
+func (c *cancelAfter) Err() error {
+	if c.checks--; c.checks < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestCountIntersectionsContextCompletes(t *testing.T) {
+	segments := generateGridSegments(20, 100)
+	opts := benott.Options{MaxIntersections: 400, MaxEvents: 1000}
+	count, err := benott.CountIntersectionsContext(context.Background(), segments, opts)
+	if err != nil || count != 400 {
+		t.Errorf("Expected 400 intersections and no error, got %d and %v", count, err)
+	}
+}
+
+func TestCountIntersectionsContextBudgets(t *testing.T) {
+	segments := generateGridSegments(100, 1000) // 10000 intersections.
+	testCases := []struct {
+		name string
+		opts benott.Options
+		err  error
+	}{
+		{"MaxIntersections", benott.Options{MaxIntersections: 500}, benott.ErrTooManyIntersections},
+		{"MaxEvents", benott.Options{MaxEvents: 500}, benott.ErrTooManyEvents},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			count, err := benott.CountIntersectionsContext(context.Background(), segments, tc.opts)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("Expected %v, got %v", tc.err, err)
+			}
+			var incomplete *benott.IncompleteError
+			if !errors.As(err, &incomplete) || incomplete.Count != count {
+				t.Fatalf("Expected an IncompleteError with count %d, got %v", count, err)
+			}
+			if count == 0 || count >= 10000 {
+				t.Errorf("Expected a partial count, got %d", count)
+			}
+		})
+	}
+}
+
+func TestCountIntersectionsContextDensePoint(t *testing.T) {
+	// Every segment passes through the origin, so a single event point holds
+	// almost all of the n*(n-1)/2 intersecting pairs.
+	const n = 6000
+	segments := make([]benott.Segment, n)
+	for i := range segments {
+		sin, cos := math.Sincos(math.Pi * (float64(i) + 0.5) / n)
+		segments[i] = benott.Segment{P1: benott.Point{-100 * cos, -100 * sin}, P2: benott.Point{100 * cos, 100 * sin}}
+	}
+
+	for _, robust := range []bool{false, true} {
+		count, err := benott.CountIntersectionsContext(context.Background(), segments, benott.Options{MaxIntersections: 10, Robust: robust})
+		if !errors.Is(err, benott.ErrTooManyIntersections) || count != 11 {
+			t.Errorf("Robust %v: expected 11 intersections and ErrTooManyIntersections, got %d and %v", robust, count, err)
+		}
+	}
+
+	// Canceled while the pairs at the origin are counted.
+	count, err := benott.CountIntersectionsContext(&cancelAfter{Context: context.Background(), checks: 100}, segments, benott.Options{})
+	if !errors.Is(err, context.Canceled) || count == 0 || count >= n*(n-1)/2 {
+		t.Errorf("Expected a partial count and context.Canceled, got %d and %v", count, err)
+	}
+}
+
+func TestCountIntersectionsContextCanceled(t *testing.T) {
+	segments := generateGridSegments(100, 1000)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	count, err := benott.CountIntersectionsContext(ctx, segments, benott.Options{})
+	if !errors.Is(err, context.Canceled) || count != 0 {
+		t.Errorf("Expected no intersections and context.Canceled, got %d and %v", count, err)
+	}
+
+	// Canceled after a few periodic checks, in the middle of the sweep.
+	count, err = benott.CountIntersectionsContext(&cancelAfter{Context: context.Background(), checks: 5}, segments, benott.Options{})
+	if !errors.Is(err, context.Canceled) || count == 0 || count >= 10000 {
+		t.Errorf("Expected a partial count and context.Canceled, got %d and %v", count, err)
+	}
+}
+
+// --- Observer ---
+
+// recorder is an Observer writing down every step of a sweep.
+type recorder struct {
+	steps []string
+}
+
+func (r *recorder) Event(p benott.Point, typ benott.EventType, seg, other int) {
+	r.steps = append(r.steps, fmt.Sprintf("event %v %d %d %d", p, typ, seg, other))
+}
+
+func (r *recorder) Add(p benott.Point, seg int) {
+	r.steps = append(r.steps, fmt.Sprintf("add %v %d", p, seg))
+}
+
+func (r *recorder) Remove(p benott.Point, seg int) {
+	r.steps = append(r.steps, fmt.Sprintf("remove %v %d", p, seg))
+}
+
+func (r *recorder) Check(p benott.Point, a, b int, q benott.Point, result benott.CheckResult) {
+	r.steps = append(r.steps, fmt.Sprintf("check %v %d %d %v %d", p, a, b, q, result))
+}
+
+func (r *recorder) Block(p benott.Point, before, after []int) {
+	r.steps = append(r.steps, fmt.Sprintf("block %v %v %v", p, before, after))
+}
+
+func TestObserverSeesEverySweepStep(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+	}
+	expected := []string{
+		"event {0 0} 0 0 -1",
+		"add {0 0} 0",
+		"event {0 5} 0 2 -1",
+		"add {0 5} 2",
+		"check {0 5} 0 2 {5 5} 1",
+		"event {0 10} 0 1 -1",
+		"add {0 10} 1",
+		"check {0 10} 2 1 {5 5} 1",
+		"event {5 5} 2 0 2",
+		"event {5 5} 2 2 1",
+		"block {5 5} [0 2 1] [1 2 0]",
+		"event {10 0} 1 1 -1",
+		"remove {10 0} 1",
+		"event {10 5} 1 2 -1",
+		"remove {10 5} 2",
+		"event {10 10} 1 0 -1",
+		"remove {10 10} 0",
+	}
+	// The exact sweep of robust mode takes the same steps.
+	for _, opts := range []benott.Options{{}, {Robust: true}} {
+		r := &recorder{}
+		opts.Observer = r
+		if count := benott.CountIntersectionsWithOptions(segments, opts); count != 3 {
+			t.Errorf("Options %+v: expected 3 intersections, got %d", opts, count)
+		}
+		if !reflect.DeepEqual(r.steps, expected) {
+			t.Errorf("Options %+v: expected steps\n%s\ngot\n%s", opts, strings.Join(expected, "\n"), strings.Join(r.steps, "\n"))
+		}
+	}
+}
+
+func TestObserverDoesNotChangeResults(t *testing.T) {
+	segments := generateRandomSegments(200, 100)
+	for _, opts := range []benott.Options{{}, {Robust: true, ReportOverlaps: true}} {
+		expected := benott.CountIntersectionsWithOptions(segments, opts)
+		opts.Observer = &recorder{}
+		if actual := benott.CountIntersectionsWithOptions(segments, opts); actual != expected {
+			t.Errorf("Expected %d intersections with an observer, got %d", expected, actual)
+		}
+	}
+}
+
+// statusRecorder is a recorder that also writes down the sweep-line status.
+type statusRecorder struct {
+	recorder
+}
+
+func (r *statusRecorder) Status(p benott.Point, segments []int) {
+	r.steps = append(r.steps, fmt.Sprintf("status %v %v", p, segments))
+}
+
+func TestStatusObserverSeesTheStatus(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{0, 0}, P2: benott.Point{10, 10}},
+		{P1: benott.Point{0, 10}, P2: benott.Point{10, 0}},
+		{P1: benott.Point{0, 5}, P2: benott.Point{10, 5}},
+	}
+	r := &statusRecorder{}
+	benott.CountIntersectionsWithOptions(segments, benott.Options{Observer: r})
+
+	var statuses []string
+	for _, step := range r.steps {
+		if strings.HasPrefix(step, "status") {
+			statuses = append(statuses, step)
+		}
+	}
+	expected := []string{
+		"status {0 0} [0]",
+		"status {0 5} [0 2]",
+		"status {0 10} [0 2 1]",
+		"status {5 5} [1 2 0]",
+		"status {10 0} [2 0]",
+		"status {10 5} [0]",
+		"status {10 10} []",
+	}
+	if !reflect.DeepEqual(statuses, expected) {
+		t.Errorf("Expected statuses\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(statuses, "\n"))
+	}
+}
+
+// slabChecker is a StatusObserver, safe for concurrent use, checking that
+// every segment it is shown passes through the point it is shown at.
+type slabChecker struct {
+	t        *testing.T
+	segments []benott.Segment
+	mu       sync.Mutex
+	added    map[int]bool
+}
+
+// through reports an error unless the input segment seg passes within the
+// tolerance of p.
+func (c *slabChecker) through(p benott.Point, seg int) {
+	if seg < 0 || seg >= len(c.segments) {
+		c.t.Errorf("Segment %d at %v is not in the input", seg, p)
+		return
+	}
+	s := c.segments[seg]
+	dx, dy := s.P2.X-s.P1.X, s.P2.Y-s.P1.Y
+	distance := math.Abs(dx*(p.Y-s.P1.Y)-dy*(p.X-s.P1.X)) / math.Hypot(dx, dy)
+	if distance > 1e-9 || p.X < min(s.P1.X, s.P2.X)-1e-9 || p.X > max(s.P1.X, s.P2.X)+1e-9 {
+		c.t.Errorf("Segment %d, %v, does not pass through %v", seg, s, p)
+	}
+}
+
+func (c *slabChecker) Event(p benott.Point, typ benott.EventType, seg, other int) {
+	c.through(p, seg)
+	if other >= 0 {
+		c.through(p, other)
+	}
+}
+
+func (c *slabChecker) Add(p benott.Point, seg int) {
+	c.through(p, seg)
+	c.mu.Lock()
+	c.added[seg] = true
+	c.mu.Unlock()
+}
+
+func (c *slabChecker) Remove(p benott.Point, seg int) { c.through(p, seg) }
+
+func (c *slabChecker) Check(p benott.Point, a, b int, q benott.Point, result benott.CheckResult) {
+	if result == benott.CheckScheduled {
+		c.through(q, a)
+		c.through(q, b)
+	}
+}
+
+func (c *slabChecker) Block(p benott.Point, before, after []int) {
+	for _, seg := range before {
+		c.through(p, seg)
+	}
+}
+
+func (c *slabChecker) Status(p benott.Point, segments []int) {
+	for _, seg := range segments {
+		if seg < 0 || seg >= len(c.segments) {
+			c.t.Errorf("Segment %d in the status at %v is not in the input", seg, p)
+		}
+	}
+}
+
+func TestObserverSeesInputIndicesInParallel(t *testing.T) {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	segments := make([]benott.Segment, 1000)
+	for i := range segments {
+		x, y := rng.Float64()*100, rng.Float64()*100
+		segments[i] = benott.Segment{P1: benott.Point{x, y}, P2: benott.Point{x + rng.Float64()*10 - 5, y + rng.Float64()*10 - 5}}
+	}
+
+	for _, robust := range []bool{false, true} {
+		c := &slabChecker{t: t, segments: segments, added: map[int]bool{}}
+		benott.CountIntersectionsParallelWithOptions(segments, 8, benott.Options{Robust: robust, Observer: c})
+		// Every segment is added to the status of some slab.
+		if len(c.added) != len(segments) {
+			t.Errorf("Robust %v: expected all %d segments to be added, got %d", robust, len(segments), len(c.added))
+		}
+	}
+}
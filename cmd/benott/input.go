@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/GregoryKogan/benott"
+	"github.com/GregoryKogan/benott/encoding/wkt"
+)
+
+// decoders maps each input format to the function decoding it.
+var decoders = map[string]func([]byte) ([]benott.Segment, error){
+	"csv":     decodeCSV,
+	"wkt":     decodeWKT,
+	"wkb":     decodeWKB,
+	"geojson": decodeGeoJSON,
+}
+
+// detect guesses the format of data read from the named file, from its
+// extension or else from its first characters.
+func detect(name string, data []byte) string {
+	switch filepath.Ext(name) {
+	case ".csv":
+		return "csv"
+	case ".wkt":
+		return "wkt"
+	case ".wkb":
+		return "wkb"
+	case ".json", ".geojson":
+		return "geojson"
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		return "geojson"
+	}
+	// A CSV header may start with a letter too, but not with a geometry.
+	head := strings.ToUpper(string(data[:min(len(data), len("MULTILINESTRING"))]))
+	for _, prefix := range []string{"LINESTRING", "MULTILINESTRING", "SRID="} {
+		if strings.HasPrefix(head, prefix) {
+			return "wkt"
+		}
+	}
+	return "csv"
+}
+
+// polyline appends the consecutive segments of a polyline to segments.
+func polyline(segments []benott.Segment, points []benott.Point) []benott.Segment {
+	for i := 1; i < len(points); i++ {
+		segments = append(segments, benott.Segment{P1: points[i-1], P2: points[i]})
+	}
+	return segments
+}
+
+// decodeCSV reads one segment per record of x1,y1,x2,y2. Further fields are
+// ignored, as are lines starting with # and a first record whose first four
+// fields are not numbers, which is taken as a header.
+func decodeCSV(data []byte) ([]benott.Segment, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+
+	var segments []benott.Segment
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			return segments, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		if len(record) < 4 {
+			return nil, fmt.Errorf("line %d: expected x1,y1,x2,y2, got %d fields", line, len(record))
+		}
+		var v [4]float64
+		var bad error
+		numbers := 0
+		for i := range v {
+			if v[i], err = strconv.ParseFloat(strings.TrimSpace(record[i]), 64); err == nil {
+				numbers++
+			} else if bad == nil {
+				bad = err
+			}
+		}
+		if bad != nil {
+			// A first record with no number in it is a header; one with some
+			// numbers is a malformed segment.
+			if first && numbers == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: %w", line, bad)
+		}
+		segments = append(segments, benott.Segment{P1: benott.Point{X: v[0], Y: v[1]}, P2: benott.Point{X: v[2], Y: v[3]}})
+	}
+}
+
+// decodeWKT reads WKT LINESTRING and MULTILINESTRING geometries.
+func decodeWKT(data []byte) ([]benott.Segment, error) {
+	segments, _, err := wkt.Unmarshal(string(data))
+	return segments, err
+}
+
+// decodeWKB reads WKB LINESTRING and MULTILINESTRING geometries, either
+// binary and concatenated, or hexadecimal and separated by white space.
+func decodeWKB(data []byte) ([]benott.Segment, error) {
+	if isHex(data) {
+		segments, _, err := wkt.UnmarshalHexWKB(string(data))
+		return segments, err
+	}
+	segments, _, err := wkt.UnmarshalWKB(data)
+	return segments, err
+}
+
+// isHex reports whether data holds only hexadecimal digits and white space.
+func isHex(data []byte) bool {
+	for _, c := range data {
+		if !strings.ContainsRune("0123456789abcdefABCDEF \t\r\n", rune(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// geoJSON is any GeoJSON object, with the members used to find line strings.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Features    []geoJSON       `json:"features"`
+}
+
+// decodeGeoJSON reads the LineString and MultiLineString geometries of a
+// GeoJSON geometry, feature or collection of either. Other geometries are
+// ignored.
+func decodeGeoJSON(data []byte) ([]benott.Segment, error) {
+	var obj geoJSON
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	return obj.appendSegments(nil)
+}
+
+// appendSegments appends the segments of the line strings in obj.
+func (obj *geoJSON) appendSegments(segments []benott.Segment) ([]benott.Segment, error) {
+	var err error
+	switch obj.Type {
+	case "FeatureCollection":
+		for i := range obj.Features {
+			if segments, err = obj.Features[i].appendSegments(segments); err != nil {
+				return nil, err
+			}
+		}
+	case "Feature":
+		if obj.Geometry != nil {
+			return obj.Geometry.appendSegments(segments)
+		}
+	case "GeometryCollection":
+		for i := range obj.Geometries {
+			if segments, err = obj.Geometries[i].appendSegments(segments); err != nil {
+				return nil, err
+			}
+		}
+	case "LineString":
+		var positions [][]float64
+		if err := json.Unmarshal(obj.Coordinates, &positions); err != nil {
+			return nil, fmt.Errorf("LineString: %w", err)
+		}
+		return appendPositions(segments, positions)
+	case "MultiLineString":
+		var lines [][][]float64
+		if err := json.Unmarshal(obj.Coordinates, &lines); err != nil {
+			return nil, fmt.Errorf("MultiLineString: %w", err)
+		}
+		for _, positions := range lines {
+			if segments, err = appendPositions(segments, positions); err != nil {
+				return nil, err
+			}
+		}
+	case "":
+		return nil, errors.New("GeoJSON object without a type")
+	}
+	return segments, nil
+}
+
+// appendPositions appends the segments of a polyline given as GeoJSON
+// positions.
+func appendPositions(segments []benott.Segment, positions [][]float64) ([]benott.Segment, error) {
+	points := make([]benott.Point, len(positions))
+	for i, pos := range positions {
+		if len(pos) < 2 {
+			return nil, fmt.Errorf("position with %d coordinates", len(pos))
+		}
+		points[i] = benott.Point{X: pos[0], Y: pos[1]}
+	}
+	return polyline(segments, points), nil
+}
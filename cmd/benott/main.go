@@ -0,0 +1,211 @@
+// Command benott counts and reports the intersections of line segments read
+// from a file or standard input.
+//
+// Usage:
+//
+//	benott [flags] [file]
+//
+// Segments are read as CSV rows of x1,y1,x2,y2, as WKT or WKB LINESTRING and
+// MULTILINESTRING geometries, or as GeoJSON holding LineString and
+// MultiLineString geometries, features or collections of them. WKB is read
+// as binary or, as output by PostGIS, in hexadecimal. Polylines are broken
+// into their consecutive segments, numbered from 0 in input order. The format
+// is detected from the file extension or the content unless -format is given;
+// WKB is only detected from the .wkb extension.
+//
+// The mode selects what is computed:
+//
+//	count   the number of intersecting pairs, with CountIntersections (default)
+//	naive   the same, checking every pair with CountIntersectionsNaive
+//	points  every intersection point with the segments through it
+//	pairs   every intersecting pair of segments
+//
+// Results are printed as text, or as a JSON object with -output json. With
+// -time, the time spent reading the input and computing the result is
+// printed to standard error, or added to the JSON object.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/GregoryKogan/benott"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// errUsage reports invalid command-line arguments, after the usage has been
+// printed.
+var errUsage = errors.New("invalid usage")
+
+// settings holds the parsed command line.
+type settings struct {
+	file    string
+	format  string
+	mode    string
+	output  string
+	timing  bool
+	options benott.Options
+}
+
+// run runs the command with args and returns its exit status: 0 on success, 1
+// if the input cannot be processed and 2 for invalid arguments.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	s, err := parse(args, stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	case err != nil:
+		fmt.Fprintln(stderr, "benott:", err)
+		return 2
+	}
+	if err := s.execute(stdin, stdout, stderr); err != nil {
+		fmt.Fprintln(stderr, "benott:", err)
+		return 1
+	}
+	return 0
+}
+
+// parse parses the command line.
+func parse(args []string, stderr io.Writer) (*settings, error) {
+	s := &settings{}
+	fs := flag.NewFlagSet("benott", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: benott [flags] [file]")
+		fmt.Fprintln(stderr, "Counts or reports the intersections of the segments in file, or standard input.")
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&s.format, "format", "auto", "input `format`: auto, csv, wkt, wkb or geojson")
+	fs.StringVar(&s.mode, "mode", "count", "`what` to compute: count, naive, points or pairs")
+	fs.StringVar(&s.output, "output", "text", "output `format`: text or json")
+	fs.BoolVar(&s.timing, "time", false, "report the time spent reading and computing")
+	fs.Float64Var(&s.options.Epsilon, "epsilon", 0, "`tolerance` for coincident points, 1e-9 if not positive")
+	tolerance := fs.String("tolerance", "absolute", "`mode` of -epsilon: absolute, or relative to the input magnitude")
+	endpoints := fs.String("endpoints", "tjunctions", "endpoint `policy`: tjunctions, proper or shared")
+	fs.BoolVar(&s.options.Robust, "robust", false, "decide geometric tests exactly")
+	fs.BoolVar(&s.options.ReportOverlaps, "overlaps", false, "count collinear overlaps")
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, err
+		}
+		return nil, errUsage
+	}
+
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		s.file = fs.Arg(0)
+	default:
+		fs.Usage()
+		return nil, errUsage
+	}
+
+	switch *tolerance {
+	case "absolute":
+		s.options.Tolerance = benott.AbsoluteTolerance
+	case "relative":
+		s.options.Tolerance = benott.RelativeTolerance
+	default:
+		return nil, fmt.Errorf("unknown tolerance %q", *tolerance)
+	}
+	switch *endpoints {
+	case "tjunctions":
+		s.options.Endpoints = benott.IncludeTJunctions
+	case "proper":
+		s.options.Endpoints = benott.ProperCrossingsOnly
+	case "shared":
+		s.options.Endpoints = benott.IncludeSharedEndpoints
+	default:
+		return nil, fmt.Errorf("unknown endpoint policy %q", *endpoints)
+	}
+	if !slices.Contains([]string{"auto", "csv", "wkt", "wkb", "geojson"}, s.format) {
+		return nil, fmt.Errorf("unknown input format %q", s.format)
+	}
+	if !slices.Contains([]string{"count", "naive", "points", "pairs"}, s.mode) {
+		return nil, fmt.Errorf("unknown mode %q", s.mode)
+	}
+	if !slices.Contains([]string{"text", "json"}, s.output) {
+		return nil, fmt.Errorf("unknown output format %q", s.output)
+	}
+	return s, nil
+}
+
+// execute reads the segments, computes the result and writes it.
+func (s *settings) execute(stdin io.Reader, stdout, stderr io.Writer) error {
+	start := time.Now()
+	segments, err := s.read(stdin)
+	if err != nil {
+		return err
+	}
+	read := time.Since(start)
+
+	start = time.Now()
+	r := &report{Segments: len(segments)}
+	switch s.mode {
+	case "count":
+		r.Count = benott.CountIntersectionsWithOptions(segments, s.options)
+	case "naive":
+		r.Count = benott.CountIntersectionsNaiveWithOptions(segments, s.options)
+	case "points":
+		r.Intersections = benott.FindIntersectionsWithOptions(segments, s.options)
+	case "pairs":
+		r.Pairs = benott.IntersectingPairsWithOptions(segments, s.options)
+	}
+	if s.timing {
+		r.timing = &timing{Read: read, Compute: time.Since(start)}
+	}
+
+	if s.output == "json" {
+		return r.writeJSON(stdout, s.mode)
+	}
+	if err := r.writeText(stdout, s.mode); err != nil {
+		return err
+	}
+	if r.timing != nil {
+		fmt.Fprintf(stderr, "read %d segments in %v, computed %s in %v\n", r.Segments, r.timing.Read, s.mode, r.timing.Compute)
+	}
+	return nil
+}
+
+// read reads the segments from the input file, or stdin if there is none, and
+// checks that they can be swept.
+func (s *settings) read(stdin io.Reader) ([]benott.Segment, error) {
+	in, name := stdin, "stdin"
+	if s.file != "" && s.file != "-" {
+		f, err := os.Open(s.file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		in, name = f, s.file
+	}
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	format := s.format
+	if format == "auto" {
+		format = detect(strings.ToLower(s.file), data)
+	}
+	segments, err := decoders[format](data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	var invalid *benott.SegmentError
+	if errors.As(benott.Validate(segments, s.options), &invalid) {
+		return nil, fmt.Errorf("%s: segment %d: %w", name, invalid.Index, invalid.Err)
+	}
+	return segments, nil
+}
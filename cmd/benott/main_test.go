@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// star holds three segments crossing at (5, 5) in every input format.
+var star = map[string]string{
+	"csv":     "x1,y1,x2,y2\n0,0,10,10\n0,10,10,0\n# The horizontal one.\n0,5,10,5\n",
+	"wkt":     "SRID=4326;MULTILINESTRING((0 0, 10 10), EMPTY, (0 10, 10 0))\nLINESTRING Z (0 5 1, 10 5 1)",
+	"geojson": `{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "MultiLineString", "coordinates": [[[0, 0], [10, 10]], [[0, 10], [10, 0]]]}}, {"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 1]}}, {"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 5], [10, 5]]}}]}`,
+}
+
+// runWith runs the command with args on input, and returns its exit status,
+// standard output and standard error.
+func runWith(input string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(input), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// --- Input ---
+
+func TestDecodeFormats(t *testing.T) {
+	expected := []benott.Segment{
+		{P1: benott.Point{X: 0, Y: 0}, P2: benott.Point{X: 10, Y: 10}},
+		{P1: benott.Point{X: 0, Y: 10}, P2: benott.Point{X: 10, Y: 0}},
+		{P1: benott.Point{X: 0, Y: 5}, P2: benott.Point{X: 10, Y: 5}},
+	}
+	for format, input := range star {
+		if detected := detect("", []byte(input)); detected != format {
+			t.Errorf("Expected %s to be detected, got %s", format, detected)
+		}
+		segments, err := decoders[format]([]byte(input))
+		if err != nil {
+			t.Errorf("Decoding %s failed: %v", format, err)
+		}
+		if !reflect.DeepEqual(segments, expected) {
+			t.Errorf("Decoding %s, expected %v, got %v", format, expected, segments)
+		}
+	}
+}
+
+func TestDecodePolylines(t *testing.T) {
+	segments, err := decodeWKT([]byte("linestring (0 0, 1 1, 2 0, 3 1e0)"))
+	if err != nil {
+		t.Fatalf("Decoding failed: %v", err)
+	}
+	if len(segments) != 3 || segments[1].P1 != segments[0].P2 || segments[2].P2 != (benott.Point{X: 3, Y: 1}) {
+		t.Errorf("Expected the 3 consecutive segments of the polyline, got %v", segments)
+	}
+}
+
+func TestDecodeWKB(t *testing.T) {
+	// LINESTRING(0 0,10 10) and LINESTRING(0 10,10 0) in hexadecimal WKB.
+	text := "0102000000020000000000000000000000000000000000000000000000000024400000000000002440\n" +
+		"0102000000020000000000000000000000000000000000244000000000000024400000000000000000\n"
+	if code, stdout, stderr := runWith(text, "-format", "wkb"); code != 0 || stdout != "1\n" {
+		t.Errorf("Expected 1 intersection, got %d %q %q", code, stdout, stderr)
+	}
+
+	data, err := hex.DecodeString(strings.ReplaceAll(text, "\n", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "cross.wkb")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, stderr := runWith("", "-mode", "points", path); code != 0 || stdout != "5 5: 0 1\n" {
+		t.Errorf("Expected the crossing, got %d %q %q", code, stdout, stderr)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		format, input string
+	}{
+		{"csv", "0,0,1,1\n0,0,1\n"},
+		{"csv", "0,0,1,1\n0,0,1,x\n"},
+		{"csv", "1,2,3,4x\n0,0,1,1\n"},
+		{"wkt", "LINESTRING (0 0, 1)"},
+		{"wkt", "LINESTRING (0 0, 1 1"},
+		{"wkt", "POLYGON ((0 0, 1 1, 1 0, 0 0))"},
+		{"wkt", "LINESTRING (0 0, 1 1) 42"},
+		{"geojson", `{"type": "LineString", "coordinates": [[0, 0], [1]]}`},
+		{"geojson", `{"coordinates": []}`},
+		{"geojson", `[`},
+	}
+	for _, tt := range tests {
+		if _, err := decoders[tt.format]([]byte(tt.input)); err == nil {
+			t.Errorf("Expected an error decoding %s %q", tt.format, tt.input)
+		}
+	}
+}
+
+// --- Output ---
+
+func TestModes(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{nil, "3\n"},
+		{[]string{"-mode", "naive"}, "3\n"},
+		{[]string{"-mode", "points"}, "5 5: 0 1 2\n"},
+		{[]string{"-mode", "pairs"}, "0 1\n0 2\n1 2\n"},
+		{[]string{"-mode", "pairs", "-endpoints", "proper", "-robust"}, "0 1\n0 2\n1 2\n"},
+		{[]string{"-output", "json"}, `{"segments":3,"count":3}` + "\n"},
+		{[]string{"-output", "json", "-mode", "points"}, `{"segments":3,"intersections":[{"kind":"point","point":[5,5],"segments":[0,1,2]}]}` + "\n"},
+		{[]string{"-output", "json", "-mode", "pairs"}, `{"segments":3,"pairs":[[0,1],[0,2],[1,2]]}` + "\n"},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runWith(star["csv"], tt.args...)
+		if code != 0 || stdout != tt.expected || stderr != "" {
+			t.Errorf("With %v, expected %q, got %d %q %q", tt.args, tt.expected, code, stdout, stderr)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	input := "0,0,4,0\n2,0,6,0\n6,0,6,5\n"
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-mode", "points"}, ""},
+		{[]string{"-mode", "points", "-overlaps"}, "2 0 - 4 0: 0 1\n"},
+		{[]string{"-mode", "points", "-endpoints", "shared"}, "6 0: 1 2\n"},
+		{[]string{"-output", "json", "-mode", "pairs"}, `{"segments":3,"pairs":[]}` + "\n"},
+		{[]string{"-output", "json", "-mode", "points", "-overlaps"}, `{"segments":3,"intersections":[{"kind":"overlap","point":[2,0],"end":[4,0],"segments":[0,1]}]}` + "\n"},
+	}
+	for _, tt := range tests {
+		code, stdout, stderr := runWith(input, tt.args...)
+		if code != 0 || stdout != tt.expected || stderr != "" {
+			t.Errorf("With %v, expected %q, got %d %q %q", tt.args, tt.expected, code, stdout, stderr)
+		}
+	}
+
+	// A segment stopping 0.001 short of another touches it only within a
+	// tolerance of 1e-4 relative to the largest coordinate, 20.
+	input = "0,3,9.999,3\n10,0,10,20\n"
+	for args, expected := range map[string]string{
+		"-epsilon 1e-4":                     "0\n",
+		"-epsilon 1e-4 -tolerance relative": "1\n",
+	} {
+		if code, stdout, stderr := runWith(input, strings.Fields(args)...); code != 0 || stdout != expected {
+			t.Errorf("With %s, expected %q, got %d %q %q", args, expected, code, stdout, stderr)
+		}
+	}
+}
+
+func TestTiming(t *testing.T) {
+	code, stdout, stderr := runWith(star["wkt"], "-time")
+	if code != 0 || stdout != "3\n" || !strings.HasPrefix(stderr, "read 3 segments in ") {
+		t.Errorf("Expected the count and a timing line, got %d %q %q", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runWith(star["wkt"], "-time", "-output", "json")
+	var out struct {
+		Timing map[string]float64
+	}
+	if err := json.Unmarshal([]byte(stdout), &out); code != 0 || err != nil {
+		t.Fatalf("Expected JSON output, got %d %q", code, stdout)
+	}
+	if _, ok := out.Timing["compute_seconds"]; !ok {
+		t.Errorf("Expected the compute time in %q", stdout)
+	}
+}
+
+// --- Files and Errors ---
+
+func TestReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "star.wkt")
+	if err := os.WriteFile(path, []byte("LINESTRING(0 0, 1 1)\nLINESTRING(0 1, 1 0)"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout, stderr := runWith("", path); code != 0 || stdout != "1\n" {
+		t.Errorf("Expected 1 intersection, got %d %q %q", code, stdout, stderr)
+	}
+	if code, _, stderr := runWith("", filepath.Join(t.TempDir(), "missing.csv")); code != 1 || stderr == "" {
+		t.Errorf("Expected an error for a missing file, got %d %q", code, stderr)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		args  []string
+		code  int
+	}{
+		{star["csv"], []string{"-mode", "all"}, 2},
+		{star["csv"], []string{"-endpoints", "none"}, 2},
+		{star["csv"], []string{"-tolerance", "loose"}, 2},
+		{star["csv"], []string{"-format", "shp"}, 2},
+		{star["csv"], []string{"-output", "xml"}, 2},
+		{star["csv"], []string{"-unknown"}, 2},
+		{star["csv"], []string{"a.csv", "b.csv"}, 2},
+		{star["csv"], []string{"-format", "wkt"}, 1},
+		{"0,0,NaN,1\n", nil, 1},
+	}
+	for _, tt := range tests {
+		if code, stdout, stderr := runWith(tt.input, tt.args...); code != tt.code || stdout != "" || stderr == "" {
+			t.Errorf("With %v, expected exit status %d and an error, got %d %q %q", tt.args, tt.code, code, stdout, stderr)
+		}
+	}
+	if code, _, stderr := runWith("", "-h"); code != 0 || !strings.HasPrefix(stderr, "usage: benott") {
+		t.Errorf("Expected the usage, got %d %q", code, stderr)
+	}
+}
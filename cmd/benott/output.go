@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// report is the result of a run, holding the fields set by its mode.
+type report struct {
+	Segments      int
+	Count         int
+	Intersections []benott.IntersectionResult
+	Pairs         [][2]int
+	timing        *timing
+}
+
+// timing holds the durations of the phases of a run.
+type timing struct {
+	Read, Compute time.Duration
+}
+
+// jsonReport is the JSON form of a report. Fields that the mode does not set
+// are left out.
+type jsonReport struct {
+	Segments      int                `json:"segments"`
+	Count         *int               `json:"count,omitzero"`
+	Intersections []jsonIntersection `json:"intersections,omitzero"`
+	Pairs         [][2]int           `json:"pairs,omitzero"`
+	Timing        *jsonTiming        `json:"timing,omitzero"`
+}
+
+// jsonIntersection is the JSON form of a benott.IntersectionResult. Points
+// are [x, y] arrays.
+type jsonIntersection struct {
+	Kind     string      `json:"kind"`
+	Point    [2]float64  `json:"point"`
+	End      *[2]float64 `json:"end,omitzero"`
+	Segments []int       `json:"segments"`
+}
+
+// jsonTiming is the JSON form of timing, in seconds.
+type jsonTiming struct {
+	Read    float64 `json:"read_seconds"`
+	Compute float64 `json:"compute_seconds"`
+}
+
+// writeJSON writes the report as a JSON object on a single line.
+func (r *report) writeJSON(w io.Writer, mode string) error {
+	out := jsonReport{Segments: r.Segments}
+	switch mode {
+	case "count", "naive":
+		out.Count = &r.Count
+	case "points":
+		out.Intersections = make([]jsonIntersection, len(r.Intersections))
+		for i, res := range r.Intersections {
+			out.Intersections[i] = jsonIntersection{Kind: "point", Point: [2]float64{res.Point.X, res.Point.Y}, Segments: res.Segments}
+			if res.Kind == benott.CollinearOverlap {
+				out.Intersections[i].Kind = "overlap"
+				out.Intersections[i].End = &[2]float64{res.Overlap[1].X, res.Overlap[1].Y}
+			}
+		}
+	case "pairs":
+		out.Pairs = r.Pairs
+		if out.Pairs == nil {
+			out.Pairs = [][2]int{}
+		}
+	}
+	if r.timing != nil {
+		out.Timing = &jsonTiming{Read: r.timing.Read.Seconds(), Compute: r.timing.Compute.Seconds()}
+	}
+
+	return json.NewEncoder(w).Encode(out)
+}
+
+// writeText writes the report as text: the count, or one line per point or
+// pair. A point is written as "x y: segments", and an overlap as
+// "x1 y1 - x2 y2: segments".
+func (r *report) writeText(w io.Writer, mode string) error {
+	bw := bufio.NewWriter(w)
+	switch mode {
+	case "count", "naive":
+		fmt.Fprintln(bw, r.Count)
+	case "points":
+		for _, res := range r.Intersections {
+			bw.WriteString(coords(res.Point))
+			if res.Kind == benott.CollinearOverlap {
+				bw.WriteString(" - " + coords(res.Overlap[1]))
+			}
+			bw.WriteString(":")
+			for _, seg := range res.Segments {
+				bw.WriteString(" " + strconv.Itoa(seg))
+			}
+			bw.WriteString("\n")
+		}
+	case "pairs":
+		for _, pair := range r.Pairs {
+			fmt.Fprintln(bw, pair[0], pair[1])
+		}
+	}
+	return bw.Flush()
+}
+
+// coords formats p as "x y", with the shortest representation of each
+// coordinate that reads back exactly.
+func coords(p benott.Point) string {
+	return strconv.FormatFloat(p.X, 'g', -1, 64) + " " + strconv.FormatFloat(p.Y, 'g', -1, 64)
+}
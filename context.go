@@ -0,0 +1,116 @@
+package benott
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// checkEvery is the number of steps, events taken from the queue or pairs of
+// segments tested, between two checks of the context.
+const checkEvery = 256
+
+var (
+	// ErrTooManyIntersections is reported when a sweep counts more
+	// intersections than Options.MaxIntersections.
+	ErrTooManyIntersections = errors.New("too many intersections")
+	// ErrTooManyEvents is reported when a sweep takes more events from its
+	// queue than Options.MaxEvents.
+	ErrTooManyEvents = errors.New("too many events")
+)
+
+// IncompleteError reports a sweep that was stopped before it finished. Err is
+// ErrTooManyIntersections, ErrTooManyEvents or the error of the context, so
+// callers can test for any of them with errors.Is, and recover the partial
+// count with errors.As.
+type IncompleteError struct {
+	// Count is the number of intersecting pairs counted before the sweep
+	// stopped.
+	Count int
+	// Err is the reason the sweep stopped.
+	Err error
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("benott: sweep stopped after %d intersections: %v", e.Count, e.Err)
+}
+
+func (e *IncompleteError) Unwrap() error { return e.Err }
+
+// CountIntersectionsContext is CountIntersectionsWithOptions for untrusted or
+// very large input. It checks ctx periodically while sweeping, and enforces
+// opts.MaxIntersections and opts.MaxEvents, so that input with a quadratic
+// number of intersections cannot hold up the caller for long.
+//
+// If the sweep is stopped, it returns the count reached so far together with
+// an *IncompleteError carrying the same count.
+func CountIntersectionsContext(ctx context.Context, segments []Segment, opts Options) (int, error) {
+	b := &budget{ctx: ctx, maxEvents: opts.MaxEvents, maxPairs: opts.MaxIntersections}
+	if err := ctx.Err(); err != nil {
+		return 0, &IncompleteError{Err: err}
+	}
+	cfg := opts.resolve(segments)
+	cfg.budget = b
+
+	intersections := 0
+	sweep(segments, cfg, func(m *meeting) bool {
+		intersections += len(m.pairs)
+		return true
+	})
+	if b.err != nil {
+		return intersections, &IncompleteError{Count: intersections, Err: b.err}
+	}
+	return intersections, nil
+}
+
+// budget limits the work of a sweep. It counts the events taken from the
+// queue and the intersecting pairs found, and stops the sweep when there are
+// too many of either or when ctx is done. Pairs are counted as they are
+// tested, so that a single point where many segments meet cannot hold up the
+// sweep either.
+type budget struct {
+	ctx                 context.Context
+	maxEvents, maxPairs int
+	events, pairs       int
+	// steps counts events and tested pairs, and checked is its value at the
+	// last check of ctx.
+	steps, checked int
+	// err is the reason the sweep stopped, or nil.
+	err error
+}
+
+// spend records n more events and reports whether the sweep may go on.
+func (b *budget) spend(n int) bool {
+	b.events += n
+	if b.maxEvents > 0 && b.events > b.maxEvents {
+		b.err = ErrTooManyEvents
+		return false
+	}
+	return b.step(n)
+}
+
+// test records one more pair of segments tested at an event point, counted
+// if it intersects, and reports whether the sweep may go on.
+func (b *budget) test(counted bool) bool {
+	if counted {
+		b.pairs++
+		if b.maxPairs > 0 && b.pairs > b.maxPairs {
+			b.err = ErrTooManyIntersections
+			return false
+		}
+	}
+	return b.step(1)
+}
+
+// step records n more steps, checking ctx every checkEvery of them.
+func (b *budget) step(n int) bool {
+	b.steps += n
+	if b.steps-b.checked >= checkEvery {
+		b.checked = b.steps
+		if err := b.ctx.Err(); err != nil {
+			b.err = err
+			return false
+		}
+	}
+	return true
+}
@@ -6,16 +6,17 @@ import pandas as pd
 sns.set_theme(style="whitegrid", palette="muted", font_scale=1.2)
 
 # --- Benchmark Data ---
-# This data is taken from the final, hyper-optimized benchmark run.
+# Medians of three runs of `go test -bench . -benchmem` on an Intel Xeon with
+# Go 1.27.1, as listed in the README.
 random_data = {
     "N": [10, 100, 1000, 10000],
-    "Time/Op (ns)": [2947, 76822, 1433473, 22666170],
+    "Time/Op (ns)": [14294, 1101286, 102839822, 14762380059],
 }
 
 grid_data = {
     "Segments": [20, 100, 200, 400],
     "Intersections": [100, 2500, 10000, 40000],
-    "Time/Op (ns)": [43430, 1202848, 5393481, 24858878],
+    "Time/Op (ns)": [62359, 972049, 4483621, 16285306],
 }
 
 # Convert the data into pandas DataFrames for easy plotting.
@@ -23,14 +24,14 @@ df_random = pd.DataFrame(random_data)
 df_grid = pd.DataFrame(grid_data)
 
 # --- Chart 1: Performance vs. Number of Segments (n) ---
-# This chart demonstrates the O(n log n) scaling.
+# Random input has k ≈ n² intersections, so this chart shows the (n+k) log n
+# running time growing much faster than n.
 plt.figure(figsize=(10, 6))
 plot_random = sns.lineplot(
     x="N", y="Time/Op (ns)", data=df_random, marker="o", markersize=8
 )
 
-# Use a log-log scale to visualize the near-linearithmic relationship.
-# O(n log n) appears as a slightly curved line that is close to straight on a log-log plot.
+# Use a log-log scale, on which a power law appears as a straight line.
 plot_random.set(xscale="log", yscale="log")
 
 plt.title("Performance Scaling with Number of Segments (Random Data)")
@@ -0,0 +1,180 @@
+package wkt
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// WKB geometry types.
+const (
+	wkbPoint           = 1
+	wkbLineString      = 2
+	wkbMultiPoint      = 4
+	wkbMultiLineString = 5
+)
+
+// Flags of the extended WKB written by PostGIS, set in the geometry type.
+const (
+	ewkbZ    = 0x80000000
+	ewkbM    = 0x40000000
+	ewkbSRID = 0x20000000
+)
+
+// UnmarshalWKB decodes a sequence of WKB LINESTRING and MULTILINESTRING
+// geometries, concatenated, into their segments like Unmarshal. Each geometry
+// may be in either byte order, and in the ISO or PostGIS extended variant.
+func UnmarshalWKB(data []byte) (segments []benott.Segment, sources []Source, err error) {
+	r := &reader{data: data}
+	var l lines
+	for geometry := 0; r.pos < len(r.data); geometry++ {
+		typ, dims, err := r.header()
+		if err != nil {
+			return nil, nil, err
+		}
+		switch typ {
+		case wkbLineString:
+			points, err := r.points(dims)
+			if err != nil {
+				return nil, nil, err
+			}
+			l.add(geometry, 0, points)
+		case wkbMultiLineString:
+			n, err := r.uint32()
+			if err != nil {
+				return nil, nil, err
+			}
+			for line := range int(n) {
+				typ, dims, err := r.header()
+				if err != nil {
+					return nil, nil, err
+				}
+				if typ != wkbLineString {
+					return nil, nil, r.errorf("expected a line string in a multi line string, got type %d", typ)
+				}
+				points, err := r.points(dims)
+				if err != nil {
+					return nil, nil, err
+				}
+				l.add(geometry, line, points)
+			}
+		default:
+			return nil, nil, r.errorf("unsupported geometry type %d", typ)
+		}
+	}
+	return l.segments, l.sources, nil
+}
+
+// UnmarshalHexWKB decodes hexadecimal WKB geometries separated by white
+// space, as output by PostGIS for geometry columns, like UnmarshalWKB.
+func UnmarshalHexWKB(text string) (segments []benott.Segment, sources []Source, err error) {
+	var data []byte
+	for _, field := range strings.Fields(text) {
+		if data, err = hex.AppendDecode(data, []byte(field)); err != nil {
+			return nil, nil, fmt.Errorf("wkb: %w", err)
+		}
+	}
+	return UnmarshalWKB(data)
+}
+
+// MarshalMultiPointWKB encodes the points of results as a little-endian WKB
+// MULTIPOINT, like MarshalMultiPoint.
+func MarshalMultiPointWKB(results []benott.IntersectionResult) []byte {
+	data := make([]byte, 0, 9+21*len(results))
+	data = append(data, 1)
+	data = binary.LittleEndian.AppendUint32(data, wkbMultiPoint)
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(results)))
+	for _, r := range results {
+		data = append(data, 1)
+		data = binary.LittleEndian.AppendUint32(data, wkbPoint)
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(r.Point.X))
+		data = binary.LittleEndian.AppendUint64(data, math.Float64bits(r.Point.Y))
+	}
+	return data
+}
+
+// reader reads WKB data. Its byte order is that of the geometry being read.
+type reader struct {
+	data  []byte
+	pos   int
+	order binary.ByteOrder
+}
+
+// errorf returns an error at the current position.
+func (r *reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("wkb: offset %d: %s", r.pos, fmt.Sprintf(format, args...))
+}
+
+// header reads the byte order and type of a geometry, and skips its SRID. It
+// returns the base geometry type and the number of ordinates of its points.
+func (r *reader) header() (typ uint32, dims int, err error) {
+	if r.pos == len(r.data) {
+		return 0, 0, r.errorf("unexpected end of input")
+	}
+	switch r.data[r.pos] {
+	case 0:
+		r.order = binary.BigEndian
+	case 1:
+		r.order = binary.LittleEndian
+	default:
+		return 0, 0, r.errorf("invalid byte order %d", r.data[r.pos])
+	}
+	r.pos++
+	if typ, err = r.uint32(); err != nil {
+		return 0, 0, err
+	}
+
+	dims = 2
+	if typ&ewkbZ != 0 {
+		dims++
+	}
+	if typ&ewkbM != 0 {
+		dims++
+	}
+	if typ&ewkbSRID != 0 {
+		if _, err := r.uint32(); err != nil {
+			return 0, 0, err
+		}
+	}
+	typ &^= ewkbZ | ewkbM | ewkbSRID
+	// ISO WKB adds 1000 for Z, 2000 for M and 3000 for both to the type.
+	switch typ / 1000 {
+	case 1, 2:
+		dims++
+	case 3:
+		dims += 2
+	}
+	return typ % 1000, dims, nil
+}
+
+// uint32 reads a 32-bit unsigned integer.
+func (r *reader) uint32() (uint32, error) {
+	if len(r.data)-r.pos < 4 {
+		return 0, r.errorf("unexpected end of input")
+	}
+	v := r.order.Uint32(r.data[r.pos:])
+	r.pos += 4
+	return v, nil
+}
+
+// points reads the points of a line string, with dims ordinates each.
+func (r *reader) points(dims int) ([]benott.Point, error) {
+	n, err := r.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(r.data)-r.pos) < uint64(n)*uint64(dims)*8 {
+		return nil, r.errorf("unexpected end of input")
+	}
+	points := make([]benott.Point, n)
+	for i := range points {
+		points[i].X = math.Float64frombits(r.order.Uint64(r.data[r.pos:]))
+		points[i].Y = math.Float64frombits(r.order.Uint64(r.data[r.pos+8:]))
+		r.pos += 8 * dims
+	}
+	return points, nil
+}
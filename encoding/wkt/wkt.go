@@ -0,0 +1,263 @@
+// Package wkt converts between benott segments and intersection results and
+// the Well-Known Text (WKT) and Well-Known Binary (WKB) representations of
+// geometries, as used by PostGIS and other spatial databases.
+//
+// LINESTRING and MULTILINESTRING geometries are decoded into the consecutive
+// segments of their line strings, along with a Source for every segment
+// recording the geometry and vertex it came from, so that the indices in
+// benott results can be traced back to the input. Intersection results are
+// encoded as a MULTIPOINT.
+//
+// Both the standard forms and the extended forms written by PostGIS are read:
+// an SRID prefix is skipped, and Z and M ordinates are ignored.
+package wkt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// Source locates a decoded segment in the input geometries.
+type Source struct {
+	// Geometry is the position of the geometry in the input, counting from 0.
+	Geometry int
+	// Line is the position of the line string in a MULTILINESTRING, or 0 for
+	// a LINESTRING.
+	Line int
+	// Vertex is the position in its line string of the first endpoint of the
+	// segment, which runs to the next vertex.
+	Vertex int
+}
+
+// lines collects the segments of decoded line strings and their sources.
+type lines struct {
+	segments []benott.Segment
+	sources  []Source
+}
+
+// add adds the consecutive segments of a line string. Repeated vertices make
+// zero-length segments, which are kept so that every vertex is accounted for.
+func (l *lines) add(geometry, line int, points []benott.Point) {
+	for i := 1; i < len(points); i++ {
+		l.segments = append(l.segments, benott.Segment{P1: points[i-1], P2: points[i]})
+		l.sources = append(l.sources, Source{Geometry: geometry, Line: line, Vertex: i - 1})
+	}
+}
+
+// Unmarshal decodes a sequence of WKT LINESTRING and MULTILINESTRING
+// geometries, separated by white space, commas or semicolons, into their
+// segments. sources[i] tells where segments[i] came from. Keywords are not
+// case sensitive, and EMPTY geometries and line strings are allowed.
+func Unmarshal(text string) (segments []benott.Segment, sources []Source, err error) {
+	sc := &scanner{text: text}
+	var l lines
+	for geometry := 0; ; geometry++ {
+		sc.skip(",;")
+		if sc.pos == len(sc.text) {
+			return l.segments, l.sources, nil
+		}
+		tag := strings.ToUpper(sc.word())
+		if tag == "SRID" {
+			if err := sc.srid(); err != nil {
+				return nil, nil, err
+			}
+			tag = strings.ToUpper(sc.word())
+		}
+		switch tag {
+		case "LINESTRING":
+			points, err := sc.lineString()
+			if err != nil {
+				return nil, nil, err
+			}
+			l.add(geometry, 0, points)
+		case "MULTILINESTRING":
+			parts, err := sc.multiLineString()
+			if err != nil {
+				return nil, nil, err
+			}
+			for line, points := range parts {
+				l.add(geometry, line, points)
+			}
+		case "":
+			return nil, nil, sc.errorf("expected a geometry")
+		default:
+			return nil, nil, sc.errorf("unsupported geometry %s", tag)
+		}
+	}
+}
+
+// MarshalMultiPoint encodes the points of results as a WKT MULTIPOINT, in
+// the order of the results. A collinear overlap contributes the start of the
+// shared sub-segment, which is its Point. Coordinates are written in the
+// shortest form that reads back exactly, in exponent notation if that is
+// shorter. Without results it returns "MULTIPOINT EMPTY".
+func MarshalMultiPoint(results []benott.IntersectionResult) string {
+	if len(results) == 0 {
+		return "MULTIPOINT EMPTY"
+	}
+	var b strings.Builder
+	b.WriteString("MULTIPOINT(")
+	for i, r := range results {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('(')
+		b.WriteString(strconv.FormatFloat(r.Point.X, 'g', -1, 64))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(r.Point.Y, 'g', -1, 64))
+		b.WriteByte(')')
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// scanner reads WKT text.
+type scanner struct {
+	text string
+	pos  int
+}
+
+// errorf returns an error at the current position.
+func (sc *scanner) errorf(format string, args ...any) error {
+	return fmt.Errorf("wkt: offset %d: %s", sc.pos, fmt.Sprintf(format, args...))
+}
+
+// skip skips white space and the separators in seps.
+func (sc *scanner) skip(seps string) {
+	for sc.pos < len(sc.text) {
+		c := sc.text[sc.pos]
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' && strings.IndexByte(seps, c) < 0 {
+			return
+		}
+		sc.pos++
+	}
+}
+
+// word reads a keyword, after any white space.
+func (sc *scanner) word() string {
+	sc.skip("")
+	start := sc.pos
+	for sc.pos < len(sc.text) && ('a' <= sc.text[sc.pos]|0x20 && sc.text[sc.pos]|0x20 <= 'z') {
+		sc.pos++
+	}
+	return sc.text[start:sc.pos]
+}
+
+// peek returns the next character after any white space, or 0 at the end.
+func (sc *scanner) peek() byte {
+	sc.skip("")
+	if sc.pos == len(sc.text) {
+		return 0
+	}
+	return sc.text[sc.pos]
+}
+
+// expect reads the character c.
+func (sc *scanner) expect(c byte) error {
+	if sc.peek() != c {
+		return sc.errorf("expected %q", c)
+	}
+	sc.pos++
+	return nil
+}
+
+// srid skips the rest of a PostGIS SRID=n; prefix.
+func (sc *scanner) srid() error {
+	if err := sc.expect('='); err != nil {
+		return err
+	}
+	end := strings.IndexByte(sc.text[sc.pos:], ';')
+	if end < 0 {
+		return sc.errorf("expected ';'")
+	}
+	sc.pos += end + 1
+	return nil
+}
+
+// empty reads the optional dimension of a geometry and reports whether it is
+// EMPTY. Otherwise it reads the opening parenthesis.
+func (sc *scanner) empty() (bool, error) {
+	word := strings.ToUpper(sc.word())
+	if word == "Z" || word == "M" || word == "ZM" {
+		word = strings.ToUpper(sc.word())
+	}
+	switch word {
+	case "EMPTY":
+		return true, nil
+	case "":
+		return false, sc.expect('(')
+	}
+	return false, sc.errorf("unexpected %s", word)
+}
+
+// lineString reads the body of a LINESTRING.
+func (sc *scanner) lineString() ([]benott.Point, error) {
+	if empty, err := sc.empty(); empty || err != nil {
+		return nil, err
+	}
+	return sc.points()
+}
+
+// multiLineString reads the body of a MULTILINESTRING. An EMPTY line string
+// in it is returned as nil, keeping the positions of the others.
+func (sc *scanner) multiLineString() ([][]benott.Point, error) {
+	if empty, err := sc.empty(); empty || err != nil {
+		return nil, err
+	}
+	var parts [][]benott.Point
+	for {
+		points, err := sc.lineString()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, points)
+		if sc.peek() != ',' {
+			return parts, sc.expect(')')
+		}
+		sc.pos++
+	}
+}
+
+// points reads the points of a line string up to its closing parenthesis.
+func (sc *scanner) points() ([]benott.Point, error) {
+	var points []benott.Point
+	for {
+		var coords []float64
+		for c := sc.peek(); c != ',' && c != ')'; c = sc.peek() {
+			v, err := sc.number()
+			if err != nil {
+				return nil, err
+			}
+			coords = append(coords, v)
+		}
+		if len(coords) < 2 || len(coords) > 4 {
+			return nil, sc.errorf("expected 2 to 4 coordinates, got %d", len(coords))
+		}
+		points = append(points, benott.Point{X: coords[0], Y: coords[1]})
+		end := sc.text[sc.pos] == ')'
+		sc.pos++
+		if end {
+			return points, nil
+		}
+	}
+}
+
+// number reads a number.
+func (sc *scanner) number() (float64, error) {
+	if sc.peek() == 0 {
+		return 0, sc.errorf("unexpected end of input")
+	}
+	start := sc.pos
+	for sc.pos < len(sc.text) && strings.IndexByte("+-.0123456789eE", sc.text[sc.pos]) >= 0 {
+		sc.pos++
+	}
+	v, err := strconv.ParseFloat(sc.text[start:sc.pos], 64)
+	if err != nil {
+		sc.pos = start
+		return 0, sc.errorf("invalid number")
+	}
+	return v, nil
+}
@@ -0,0 +1,193 @@
+package wkt_test
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/GregoryKogan/benott"
+	"github.com/GregoryKogan/benott/encoding/wkt"
+)
+
+// star holds three lines crossing at (5, 5), from two geometries.
+const star = "SRID=4326;MULTILINESTRING((0 0, 10 10), EMPTY, (0 10, 10 0));\nlinestring z (0 5 1, 5 5 1, 10 5 1)"
+
+var (
+	starSegments = []benott.Segment{
+		{P1: benott.Point{X: 0, Y: 0}, P2: benott.Point{X: 10, Y: 10}},
+		{P1: benott.Point{X: 0, Y: 10}, P2: benott.Point{X: 10, Y: 0}},
+		{P1: benott.Point{X: 0, Y: 5}, P2: benott.Point{X: 5, Y: 5}},
+		{P1: benott.Point{X: 5, Y: 5}, P2: benott.Point{X: 10, Y: 5}},
+	}
+	starSources = []wkt.Source{
+		{Geometry: 0, Line: 0, Vertex: 0},
+		{Geometry: 0, Line: 2, Vertex: 0},
+		{Geometry: 1, Line: 0, Vertex: 0},
+		{Geometry: 1, Line: 0, Vertex: 1},
+	}
+)
+
+// wkb builds WKB data: a geometry header in the given byte order, followed by
+// the values, which are written as uint32 if they are ints and float64
+// otherwise.
+func wkb(order binary.AppendByteOrder, typ uint32, values ...any) []byte {
+	data := []byte{0}
+	if order == binary.LittleEndian {
+		data[0] = 1
+	}
+	data = order.AppendUint32(data, typ)
+	for _, v := range values {
+		switch v := v.(type) {
+		case int:
+			data = order.AppendUint32(data, uint32(v))
+		case float64:
+			data = order.AppendUint64(data, math.Float64bits(v))
+		case []byte:
+			data = append(data, v...)
+		}
+	}
+	return data
+}
+
+// --- Decoding ---
+
+func TestUnmarshal(t *testing.T) {
+	segments, sources, err := wkt.Unmarshal(star)
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !reflect.DeepEqual(segments, starSegments) || !reflect.DeepEqual(sources, starSources) {
+		t.Errorf("Expected %v from %v, got %v from %v", starSegments, starSources, segments, sources)
+	}
+
+	segments, sources, err = wkt.Unmarshal(" LINESTRING EMPTY\n")
+	if err != nil || len(segments) != 0 || len(sources) != 0 {
+		t.Errorf("Expected no segments, got %v %v %v", segments, sources, err)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	for _, text := range []string{
+		"LINESTRING (0 0, 1)",
+		"LINESTRING (0 0, 1 1",
+		"LINESTRING (0 0, 1 1 2 3 4)",
+		"LINESTRING (0 0, 1 x)",
+		"LINESTRING 0 0, 1 1",
+		"MULTILINESTRING ((0 0, 1 1) (2 2, 3 3))",
+		"POLYGON ((0 0, 1 1, 1 0, 0 0))",
+		"SRID=4326 LINESTRING (0 0, 1 1)",
+		"LINESTRING (0 0, 1 1) 42",
+	} {
+		if _, _, err := wkt.Unmarshal(text); err == nil || !strings.HasPrefix(err.Error(), "wkt: offset ") {
+			t.Errorf("Expected an error decoding %q, got %v", text, err)
+		}
+	}
+}
+
+func TestUnmarshalWKB(t *testing.T) {
+	// The same geometries as star: an EWKB multi line string with an SRID, in
+	// big-endian order with line strings in either byte order, then an ISO WKB
+	// line string with Z ordinates.
+	data := wkb(binary.BigEndian, 5|0x20000000, 4326, 3,
+		wkb(binary.LittleEndian, 2, 2, 0.0, 0.0, 10.0, 10.0),
+		wkb(binary.BigEndian, 2, 0),
+		wkb(binary.BigEndian, 2, 2, 0.0, 10.0, 10.0, 0.0),
+	)
+	data = append(data, wkb(binary.LittleEndian, 1002, 3, 0.0, 5.0, 1.0, 5.0, 5.0, 1.0, 10.0, 5.0, 1.0)...)
+
+	segments, sources, err := wkt.UnmarshalWKB(data)
+	if err != nil {
+		t.Fatalf("UnmarshalWKB failed: %v", err)
+	}
+	if !reflect.DeepEqual(segments, starSegments) || !reflect.DeepEqual(sources, starSources) {
+		t.Errorf("Expected %v from %v, got %v from %v", starSegments, starSources, segments, sources)
+	}
+}
+
+func TestUnmarshalHexWKB(t *testing.T) {
+	// As output by PostGIS for 'SRID=4326;LINESTRING(0 0,10 10)' and
+	// 'LINESTRING M (0 10 7,10 0 7)'.
+	text := "0102000020E6100000020000000000000000000000000000000000000000000000000024400000000000002440\n" +
+		"010200004002000000000000000000000000000000000024400000000000001C40000000000000244000000000000000000000000000001C40\n"
+	segments, sources, err := wkt.UnmarshalHexWKB(text)
+	if err != nil {
+		t.Fatalf("UnmarshalHexWKB failed: %v", err)
+	}
+	expected := starSegments[:2]
+	if !reflect.DeepEqual(segments, expected) || sources[1].Geometry != 1 {
+		t.Errorf("Expected %v, got %v from %v", expected, segments, sources)
+	}
+	if count := benott.CountIntersections(segments); count != 1 {
+		t.Errorf("Expected 1 intersection, got %d", count)
+	}
+}
+
+func TestUnmarshalWKBErrors(t *testing.T) {
+	line := wkb(binary.LittleEndian, 2, 2, 0.0, 0.0, 1.0, 1.0)
+	for name, data := range map[string][]byte{
+		"truncated":       line[:len(line)-1],
+		"byte order":      append([]byte{2}, line[1:]...),
+		"point":           wkb(binary.LittleEndian, 1, 0.0, 0.0),
+		"nested point":    wkb(binary.LittleEndian, 5, 1, wkb(binary.LittleEndian, 1, 0.0, 0.0)),
+		"huge count":      wkb(binary.LittleEndian, 2, math.MaxUint32),
+		"missing members": wkb(binary.LittleEndian, 5, 2, line),
+	} {
+		if _, _, err := wkt.UnmarshalWKB(data); err == nil || !strings.HasPrefix(err.Error(), "wkb: ") {
+			t.Errorf("Expected an error decoding the %s, got %v", name, err)
+		}
+	}
+	if _, _, err := wkt.UnmarshalHexWKB("01xy"); err == nil {
+		t.Errorf("Expected an error decoding invalid hex")
+	}
+}
+
+// --- Encoding ---
+
+func TestMarshalMultiPoint(t *testing.T) {
+	segments, sources, err := wkt.Unmarshal(star + "\nLINESTRING (-1 0.25, 4 0.25)")
+	if err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	results := benott.FindIntersections(segments)
+	if text := wkt.MarshalMultiPoint(results); text != "MULTIPOINT((0.25 0.25),(5 5))" {
+		t.Errorf("Expected both intersection points, got %s", text)
+	}
+	// The crossing at (0.25, 0.25) is between the first geometry and the last.
+	if a, b := sources[results[0].Segments[0]], sources[results[0].Segments[1]]; a.Geometry != 0 || b.Geometry != 2 {
+		t.Errorf("Expected geometries 0 and 2 to cross, got %v and %v", a, b)
+	}
+	if text := wkt.MarshalMultiPoint(nil); text != "MULTIPOINT EMPTY" {
+		t.Errorf("Expected an empty multi point, got %s", text)
+	}
+}
+
+func TestMarshalMultiPointRoundTrip(t *testing.T) {
+	results := []benott.IntersectionResult{{Point: benott.Point{X: 1e300, Y: -1e-300}}, {Point: benott.Point{X: 1e-300, Y: 0.1}}}
+	text := wkt.MarshalMultiPoint(results)
+	if len(text) > 100 {
+		t.Errorf("Expected a compact encoding, got %d bytes", len(text))
+	}
+
+	// Read the points back as the vertices of a line string.
+	line := "LINESTRING" + strings.NewReplacer("MULTIPOINT", "", "((", "(", "),(", ",", "))", ")").Replace(text)
+	segments, _, err := wkt.Unmarshal(line)
+	if err != nil {
+		t.Fatalf("Unmarshal of %s failed: %v", line, err)
+	}
+	if len(segments) != 1 || segments[0].P1 != results[0].Point || segments[0].P2 != results[1].Point {
+		t.Errorf("Expected %v and %v back from %s, got %v", results[0].Point, results[1].Point, text, segments)
+	}
+}
+
+func TestMarshalMultiPointWKB(t *testing.T) {
+	results := []benott.IntersectionResult{{Point: benott.Point{X: 0.25, Y: 0.25}}, {Point: benott.Point{X: 5, Y: -5}}}
+	expected := wkb(binary.LittleEndian, 4, 2,
+		wkb(binary.LittleEndian, 1, 0.25, 0.25),
+		wkb(binary.LittleEndian, 1, 5.0, -5.0),
+	)
+	if data := wkt.MarshalMultiPointWKB(results); !reflect.DeepEqual(data, expected) {
+		t.Errorf("Expected %x, got %x", expected, data)
+	}
+}
@@ -29,18 +29,24 @@ type Event struct {
 // EventQueue is a min-priority queue of events, implemented using Go's container/heap.
 // Events are ordered primarily by their X-coordinate, then by their Y-coordinate
 // as a tie-breaker. This ensures the sweep-line processes points from left-to-right,
-// bottom-to-top.
+// bottom-to-top. The order is exact, so that it is a strict weak ordering even
+// for events computed at the same point with rounding errors; the sweep merges
+// such events when it takes them from the queue.
 type EventQueue []*Event
 
 // Len returns the number of events in the queue.
 func (eq EventQueue) Len() int { return len(eq) }
 
 // Less reports whether the event at index i should be sorted before the event at index j.
-func (eq EventQueue) Less(i, j int) bool {
-	if eq[i].Point.X != eq[j].Point.X {
-		return eq[i].Point.X < eq[j].Point.X
+func (eq EventQueue) Less(i, j int) bool { return pointLess(eq[i].Point, eq[j].Point) }
+
+// pointLess reports whether the sweep reaches a before b: from left to right,
+// then from bottom to top.
+func pointLess(a, b Point) bool {
+	if a.X != b.X {
+		return a.X < b.X
 	}
-	return eq[i].Point.Y < eq[j].Point.Y
+	return a.Y < b.Y
 }
 
 // Swap swaps the events at indices i and j.
@@ -62,3 +68,22 @@ func (eq *EventQueue) Pop() any {
 	*eq = old[0 : n-1]
 	return item
 }
+
+// column holds the events of the column being swept: those within the
+// tolerance of its X-coordinate. The sweep line has the width of the
+// tolerance, so a column is processed from bottom to top, and events computed
+// at the same point with rounding errors end up next to each other.
+type column struct {
+	EventQueue
+	// x is the X-coordinate of the leftmost event of the column.
+	x float64
+}
+
+// Less reports whether the event at index i is below the event at index j.
+func (c *column) Less(i, j int) bool {
+	a, b := c.EventQueue[i].Point, c.EventQueue[j].Point
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
@@ -0,0 +1,721 @@
+package benott
+
+import (
+	"container/heap"
+	"math"
+	"math/big"
+)
+
+// This file implements a variant of the sweep that uses exact rational
+// arithmetic throughout. Event points are ordered exactly, the status is
+// ordered by exact comparisons of y-coordinates, and no tolerance is involved
+// at any step. It is considerably slower than the floating-point sweep and is
+// used by the integer and rational APIs, and by robust mode.
+
+// exactPoint is a point with rational coordinates.
+type exactPoint struct {
+	x, y *big.Rat
+}
+
+// cmp orders points the way the sweep reaches them: from left to right, then
+// from bottom to top.
+func (p exactPoint) cmp(q exactPoint) int {
+	if p == q {
+		return 0
+	}
+	if c := p.x.Cmp(q.x); c != 0 {
+		return c
+	}
+	return p.y.Cmp(q.y)
+}
+
+// rounded returns p rounded to float64 coordinates, and a bound on the
+// rounding error of each, which is 0 if p is exactly representable.
+func (p exactPoint) rounded() (Point, float64) {
+	x, exactX := p.x.Float64()
+	y, exactY := p.y.Float64()
+	if exactX && exactY {
+		return Point{X: x, Y: y}, 0
+	}
+	return Point{X: x, Y: y}, max(ulp(x), ulp(y))
+}
+
+// ulp returns the distance from |v| to the next larger float64, which bounds
+// the error of rounding a real number to v.
+func ulp(v float64) float64 {
+	v = math.Abs(v)
+	return math.Nextafter(v, math.Inf(1)) - v
+}
+
+// exactSegment is a segment in the exact sweep. Its endpoints are normalized
+// so that p1 is reached first.
+type exactSegment struct {
+	p1, p2 exactPoint
+	// vertical is set when both endpoints have the same x-coordinate.
+	vertical bool
+	// slope is the slope of a non-vertical segment, computed when first
+	// needed.
+	slope *big.Rat
+	// y caches the y-coordinate of the segment at yX, the x-coordinate of the
+	// event being processed, since every comparison at an event needs it.
+	y, yX *big.Rat
+	// side caches the orientation of the event point at sideX, sideY relative
+	// to the segment, when it took exact arithmetic to decide.
+	side         int
+	sideX, sideY *big.Rat
+
+	// integral is set when the segment came from integer input, in which case
+	// i1 and i2 hold its endpoints and orientation tests between integral
+	// segments avoid big.Rat entirely.
+	integral bool
+	i1, i2   IntPoint
+	// float is set when the segment came from float64 input, in which case f1
+	// and f2 hold its endpoints and orientation tests between such segments
+	// use the adaptive predicates.
+	float  bool
+	f1, f2 Point
+
+	// index is the position of the segment in the caller's input slice.
+	index int
+	// layer is the input set the segment belongs to, as for Segment.
+	layer int
+}
+
+// init normalizes the endpoints.
+func (s *exactSegment) init(index int) {
+	s.index = index
+	if s.p1.cmp(s.p2) > 0 {
+		s.p1, s.p2 = s.p2, s.p1
+		s.i1, s.i2 = s.i2, s.i1
+		s.f1, s.f2 = s.f2, s.f1
+	}
+	s.vertical = s.p1.x.Cmp(s.p2.x) == 0
+}
+
+// hasEndpoint reports whether p is one of the segment's endpoints. The point
+// of a zero-length segment counts as its interior, as in the floating-point
+// sweep.
+func (s *exactSegment) hasEndpoint(p exactPoint) bool {
+	return !s.degenerate() && (s.p1.cmp(p) == 0 || s.p2.cmp(p) == 0)
+}
+
+// through records that the segment passes through p, which its side cache
+// then answers.
+func (s *exactSegment) through(p exactPoint) {
+	s.side, s.sideX, s.sideY = 0, p.x, p.y
+}
+
+// degenerate reports whether the segment has zero length.
+func (s *exactSegment) degenerate() bool { return s.p1.cmp(s.p2) == 0 }
+
+// yAt returns the y-coordinate of the supporting line of a non-vertical
+// segment at x.
+func (s *exactSegment) yAt(x *big.Rat) *big.Rat {
+	if s.slope == nil {
+		dx := new(big.Rat).Sub(s.p2.x, s.p1.x)
+		s.slope = dx.Quo(new(big.Rat).Sub(s.p2.y, s.p1.y), dx)
+	}
+	y := new(big.Rat).Sub(x, s.p1.x)
+	y.Mul(y, s.slope)
+	return y.Add(y, s.p1.y)
+}
+
+// turn returns the sign of the cross product of the directions of a and b: 1
+// if b turns counter-clockwise from a, -1 if clockwise and 0 if they are
+// parallel.
+func turn(a, b *exactSegment) int {
+	switch {
+	case a.integral && b.integral:
+		return crossInt(a.i1, a.i2, b.i1, b.i2)
+	case a.float && b.float:
+		return sign(cross(a.f1, a.f2, b.f1, b.f2))
+	}
+	return crossRat(a.p1, a.p2, b.p1, b.p2)
+}
+
+// side returns the orientation of an endpoint of b, p2 if second is set and p1
+// otherwise, relative to the directed line through the endpoints of a: 1 if it
+// lies to the left, -1 to the right and 0 on it.
+func side(a, b *exactSegment, second bool) int {
+	p, ip, fp := b.p1, b.i1, b.f1
+	if second {
+		p, ip, fp = b.p2, b.i2, b.f2
+	}
+	switch {
+	case a.integral && b.integral:
+		return crossInt(a.i1, a.i2, a.i1, ip)
+	case a.float && b.float:
+		return sign(orient2d(a.f1, a.f2, fp))
+	}
+	return crossRat(a.p1, a.p2, a.p1, p)
+}
+
+// crossRat returns the sign of the cross product of the vectors b-a and d-c.
+func crossRat(a, b, c, d exactPoint) int {
+	left := diff(b.x, a.x).mul(diff(d.y, c.y))
+	right := diff(b.y, a.y).mul(diff(d.x, c.x))
+	return left.cmp(right)
+}
+
+// fraction is an unreduced rational with a positive denominator. Chaining
+// arithmetic on fractions and reducing once at the end is much cheaper than
+// reducing every intermediate big.Rat.
+type fraction struct {
+	num, den *big.Int
+}
+
+// diff returns x - y.
+func diff(x, y *big.Rat) fraction {
+	num := new(big.Int).Mul(x.Num(), y.Denom())
+	num.Sub(num, new(big.Int).Mul(y.Num(), x.Denom()))
+	return fraction{num: num, den: new(big.Int).Mul(x.Denom(), y.Denom())}
+}
+
+// mul returns f * g.
+func (f fraction) mul(g fraction) fraction {
+	return fraction{num: new(big.Int).Mul(f.num, g.num), den: new(big.Int).Mul(f.den, g.den)}
+}
+
+// add returns f + g.
+func (f fraction) add(g fraction) fraction {
+	num := new(big.Int).Mul(f.num, g.den)
+	num.Add(num, new(big.Int).Mul(g.num, f.den))
+	return fraction{num: num, den: new(big.Int).Mul(f.den, g.den)}
+}
+
+// sub returns f - g.
+func (f fraction) sub(g fraction) fraction {
+	num := new(big.Int).Mul(f.num, g.den)
+	num.Sub(num, new(big.Int).Mul(g.num, f.den))
+	return fraction{num: num, den: new(big.Int).Mul(f.den, g.den)}
+}
+
+// cmp compares f and g.
+func (f fraction) cmp(g fraction) int {
+	return new(big.Int).Mul(f.num, g.den).Cmp(new(big.Int).Mul(g.num, f.den))
+}
+
+// exactIntersection reports whether the non-parallel segments a and b meet at
+// a single point, and returns that point.
+func exactIntersection(a, b *exactSegment) (exactPoint, bool) {
+	if turn(a, b) == 0 {
+		return exactPoint{}, false
+	}
+	o1 := side(a, b, false)
+	o2 := side(a, b, true)
+	o3 := side(b, a, false)
+	o4 := side(b, a, true)
+	if o1*o2 > 0 || o3*o4 > 0 {
+		return exactPoint{}, false
+	}
+
+	if a.float && b.float {
+		return floatIntersection(a.f1, a.f2, b.f1, b.f2), true
+	}
+
+	// Solve p1 + t*r = q1 + u*s for t = num/den, where r and s are the
+	// directions.
+	rx, ry := diff(a.p2.x, a.p1.x), diff(a.p2.y, a.p1.y)
+	sx, sy := diff(b.p2.x, b.p1.x), diff(b.p2.y, b.p1.y)
+	qx, qy := diff(b.p1.x, a.p1.x), diff(b.p1.y, a.p1.y)
+	num := qx.mul(sy).sub(qy.mul(sx))
+	den := rx.mul(sy).sub(ry.mul(sx))
+
+	// p1 + r*num/den, reduced once for each coordinate.
+	at := func(p1 *big.Rat, r fraction) *big.Rat {
+		v := fraction{num: p1.Num(), den: p1.Denom()}.mul(den).add(r.mul(num))
+		return new(big.Rat).SetFrac(v.num.Mul(v.num, den.den), v.den.Mul(v.den, den.num))
+	}
+	return exactPoint{x: at(a.p1.x, rx), y: at(a.p1.y, ry)}, true
+}
+
+// floatIntersection returns the exact point where the lines through a1, a2
+// and b1, b2 cross, which must not be parallel. Their float64 coordinates are
+// all integers times a common power of two, so the point is computed with
+// integers and reduced once.
+func floatIntersection(a1, a2, b1, b2 Point) exactPoint {
+	c, e := scaled(a1.X, a1.Y, a2.X, a2.Y, b1.X, b1.Y, b2.X, b2.Y)
+	sub := func(i, j int) *big.Int { return new(big.Int).Sub(c[i], c[j]) }
+	rx, ry, sx, sy, qx, qy := sub(2, 0), sub(3, 1), sub(6, 4), sub(7, 5), sub(4, 0), sub(5, 1)
+
+	// Solve p1 + t*r = q1 + u*s for t = num/den.
+	num := new(big.Int).Mul(qx, sy)
+	num.Sub(num, new(big.Int).Mul(qy, sx))
+	den := new(big.Int).Mul(rx, sy)
+	den.Sub(den, new(big.Int).Mul(ry, sx))
+
+	// (p1*den + r*num) / den, times 2^e.
+	at := func(p1, r *big.Int) *big.Rat {
+		v := new(big.Int).Mul(p1, den)
+		v.Add(v, r.Mul(r, num))
+		if e < 0 {
+			return new(big.Rat).SetFrac(v, new(big.Int).Lsh(den, uint(-e)))
+		}
+		return new(big.Rat).SetFrac(v.Lsh(v, uint(e)), den)
+	}
+	return exactPoint{x: at(c[0], rx), y: at(c[1], ry)}
+}
+
+// scaled returns finite values as integers, all multiplied by the same power
+// of two, 2^-e, and e.
+func scaled(values ...float64) ([]*big.Int, int) {
+	e := math.MaxInt
+	for _, v := range values {
+		if v != 0 {
+			_, exp := math.Frexp(v)
+			e = min(e, exp-53)
+		}
+	}
+	ints := make([]*big.Int, len(values))
+	for i, v := range values {
+		frac, exp := math.Frexp(v)
+		ints[i] = big.NewInt(int64(frac * (1 << 53)))
+		if v != 0 {
+			ints[i].Lsh(ints[i], uint(exp-53-e))
+		}
+	}
+	return ints, e
+}
+
+// exactComparator orders the segments in the status of the exact sweep. It
+// follows the same rules as sweepLineComparator, with exact comparisons.
+type exactComparator struct {
+	// p is the event point being processed.
+	p exactPoint
+	// pf is p rounded to float64, and err bounds the rounding error of each
+	// coordinate; it is 0 if p is exactly representable.
+	pf  Point
+	err float64
+}
+
+// setEvent moves the sweep line to the point of event e.
+func (c *exactComparator) setEvent(e *exactEvent) {
+	c.p, c.pf, c.err = e.point, e.approx, e.err
+}
+
+// yAt returns the y-coordinate of seg on the sweep line. A vertical segment is
+// placed at the current event, clamped to its own extent.
+func (c *exactComparator) yAt(seg *exactSegment) *big.Rat {
+	if seg.vertical {
+		switch {
+		case c.p.y.Cmp(seg.p1.y) < 0:
+			return seg.p1.y
+		case c.p.y.Cmp(seg.p2.y) > 0:
+			return seg.p2.y
+		}
+		return c.p.y
+	}
+	if seg.yX != c.p.x {
+		seg.y, seg.yX = seg.yAt(c.p.x), c.p.x
+	}
+	return seg.y
+}
+
+// height compares the y-coordinate of seg on the sweep line with that of the
+// current event point: it returns 1 if seg is above the point, -1 if below
+// and 0 if level with it.
+func (c *exactComparator) height(seg *exactSegment) int {
+	if seg.float && !seg.vertical {
+		// seg points rightwards, so it passes below the event point exactly
+		// when the point lies to its left.
+		return -c.side(seg)
+	}
+	return c.yAt(seg).Cmp(c.p.y)
+}
+
+// side returns the orientation of the current event point relative to the
+// directed line through the endpoints of seg, which came from float64 input,
+// like the function side. The rounded event point decides it unless it lies
+// too close to the line, within the bound on the rounding errors.
+func (c *exactComparator) side(seg *exactSegment) int {
+	a, b, q := seg.f1, seg.f2, c.pf
+	if c.p == seg.p1 || c.p == seg.p2 {
+		return 0
+	}
+	if c.err == 0 {
+		return sign(orient2d(a, b, q))
+	}
+	// Moving q by up to err in each coordinate changes the cross product by
+	// up to (|dx|+|dy|)*err, which is doubled to cover its own rounding. At
+	// extreme magnitudes the products are not accurate enough for the bound.
+	if safe(a, b, a, q) && c.err >= minSafe {
+		left := (b.X - a.X) * (q.Y - a.Y)
+		right := (b.Y - a.Y) * (q.X - a.X)
+		det := left - right
+		bound := crossErrBound*(math.Abs(left)+math.Abs(right)) + 2*(math.Abs(b.X-a.X)+math.Abs(b.Y-a.Y))*c.err
+		switch {
+		case det > bound:
+			return 1
+		case det < -bound:
+			return -1
+		}
+	}
+	if seg.sideX != c.p.x || seg.sideY != c.p.y {
+		seg.side, seg.sideX, seg.sideY = crossRat(seg.p1, seg.p2, seg.p1, c.p), c.p.x, c.p.y
+	}
+	return seg.side
+}
+
+// passes reports whether seg passes through the current event point.
+func (c *exactComparator) passes(seg *exactSegment) bool {
+	return c.height(seg) == 0 && c.within(seg)
+}
+
+// within reports whether the current event point lies between the endpoints
+// of seg in the order of the sweep.
+func (c *exactComparator) within(seg *exactSegment) bool {
+	if seg.float && c.err == 0 {
+		return !pointLess(c.pf, seg.f1) && !pointLess(seg.f2, c.pf)
+	}
+	return seg.p1.cmp(c.p) <= 0 && seg.p2.cmp(c.p) >= 0
+}
+
+// compare orders two segments by their y-coordinates on the sweep line.
+func (c *exactComparator) compare(segA, segB *exactSegment) int {
+	hA, hB := c.height(segA), c.height(segB)
+	if hA == 0 && hB == 0 && c.within(segA) && c.within(segB) {
+		return compareExactMeeting(segA, segB, false)
+	}
+	// Segments on different sides of the event point are ordered by that.
+	if hA != hB {
+		if hA < hB {
+			return -1
+		}
+		return 1
+	}
+	yA, yB := c.yAt(segA), c.yAt(segB)
+	if d := yA.Cmp(yB); d != 0 {
+		return d
+	}
+	// The segments meet elsewhere on the sweep line, below the current event
+	// if they have already been reordered there and above it if not.
+	return compareExactMeeting(segA, segB, yA.Cmp(c.p.y) > 0)
+}
+
+// compareExactMeeting orders two segments that meet on the sweep line: after
+// the meeting point the one turning clockwise from the other is lower, before
+// it higher. Collinear segments keep their input order.
+func compareExactMeeting(segA, segB *exactSegment, before bool) int {
+	// Both directions point rightwards (or up), so B is steeper exactly when
+	// it turns counter-clockwise from A.
+	if t := turn(segA, segB); t != 0 {
+		if before {
+			return t
+		}
+		return -t
+	}
+	return segA.index - segB.index
+}
+
+// exactStatus is the sweep-line status of the exact sweep.
+type exactStatus struct {
+	tree       *rbTree[*exactSegment]
+	comparator *exactComparator
+}
+
+// collectAt appends to buf, from bottom to top, every segment in the status
+// that passes through the current event point p. It also returns the nodes
+// immediately below and above that run, or `nil` where none exists.
+func (s *exactStatus) collectAt(p exactPoint, buf []*exactSegment) (run []*exactSegment, below, above *rbNode[*exactSegment]) {
+	var first *rbNode[*exactSegment]
+	for node := s.tree.root; node != s.tree.leaf; {
+		if h := s.comparator.height(node.key); h > 0 || (h == 0 && s.comparator.within(node.key)) {
+			first, node = node, node.left
+		} else {
+			node = node.right
+		}
+	}
+
+	below = s.tree.last
+	if first != nil {
+		below = first.prev
+	}
+
+	run = buf
+	above = first
+	for above != nil && s.comparator.passes(above.key) {
+		run = append(run, above.key)
+		above = above.next
+	}
+	return run, below, above
+}
+
+// passAt moves the status past p, like Status.passAt.
+func (s *exactStatus) passAt(p exactPoint, n int, below, above *rbNode[*exactSegment], starts, buf []*exactSegment) []*exactSegment {
+	s.tree.pass(s.tree.next(below), n,
+		func(seg *exactSegment) bool { return seg.p2.cmp(p) == 0 },
+		func(a, b *exactSegment) int { return compareExactMeeting(a, b, false) })
+	for _, seg := range starts {
+		if seg.p2.cmp(p) != 0 {
+			s.tree.insert(seg)
+		}
+	}
+	for node := s.tree.next(below); node != above; node = node.next {
+		buf = append(buf, node.key)
+	}
+	return buf
+}
+
+// exactEvent is an event of the exact sweep, like Event. seg is the segment
+// starting or ending at the event point; for an Intersection event, seg and
+// other are the two segments whose meeting created it.
+type exactEvent struct {
+	point      exactPoint
+	typ        EventType
+	seg, other *exactSegment
+	// approx is point rounded and err the bound on its rounding error, which
+	// order most events without comparing rationals.
+	approx Point
+	err    float64
+}
+
+// newExactEvent returns an event at p.
+func newExactEvent(p exactPoint, typ EventType, seg, other *exactSegment) exactEvent {
+	e := exactEvent{point: p, typ: typ, seg: seg, other: other}
+	switch {
+	case seg.float && p == seg.p1:
+		e.approx = seg.f1
+	case seg.float && p == seg.p2:
+		e.approx = seg.f2
+	default:
+		e.approx, e.err = p.rounded()
+	}
+	return e
+}
+
+// samePoint reports whether events e and f are at the same point.
+func (e *exactEvent) samePoint(f *exactEvent) bool {
+	if e.err == 0 && f.err == 0 {
+		return e.approx == f.approx
+	}
+	bound := 2 * (e.err + f.err)
+	if math.Abs(e.approx.X-f.approx.X) > bound || math.Abs(e.approx.Y-f.approx.Y) > bound {
+		return false
+	}
+	return e.point.cmp(f.point) == 0
+}
+
+// exactQueue is the event queue of the exact sweep, ordered exactly.
+type exactQueue []exactEvent
+
+func (q exactQueue) Len() int { return len(q) }
+
+func (q exactQueue) Less(i, j int) bool {
+	a, b := &q[i], &q[j]
+	// Rounded coordinates further apart than twice their errors, which
+	// covers the rounding of the difference, are ordered like the exact ones.
+	bound := 2 * (a.err + b.err)
+	if d := a.approx.X - b.approx.X; math.Abs(d) > bound {
+		return d < 0
+	}
+	if c := a.point.x.Cmp(b.point.x); c != 0 {
+		return c < 0
+	}
+	if d := a.approx.Y - b.approx.Y; math.Abs(d) > bound {
+		return d < 0
+	}
+	return a.point.y.Cmp(b.point.y) < 0
+}
+
+func (q exactQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *exactQueue) Push(x any)   { *q = append(*q, x.(exactEvent)) }
+func (q *exactQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// exactMeeting is an intersection found by the exact sweep. The segs slice is
+// reused by the sweep and must not be retained.
+type exactMeeting struct {
+	kind IntersectionKind
+	// point is the intersection point, or the start of an overlap.
+	point exactPoint
+	// end is the end of an overlap.
+	end exactPoint
+	// segs holds all segments through point, or the two overlapping segments.
+	segs []*exactSegment
+	// pairs holds the counted pairs among segs.
+	pairs [][2]*exactSegment
+}
+
+// exactSweep runs the Bentley-Ottmann algorithm over segments, which must have
+// been initialized, using exact arithmetic. Every setting of cfg but the
+// tolerance is used. It calls visit for every intersection it finds, like
+// sweep, and stops if visit returns false.
+func exactSweep(segments []exactSegment, cfg config, visit func(m *exactMeeting) bool) {
+	eq := make(exactQueue, 0, len(segments)*2)
+	for i := range segments {
+		s := &segments[i]
+		if cfg.zeroLengths == SkipZeroLength && s.degenerate() {
+			continue
+		}
+		// A segment clipped to a slab starts where it enters the slab.
+		start := s.p1
+		if cfg.slab != nil {
+			start = cfg.slab.enterExact(s)
+		}
+		eq = append(eq, newExactEvent(start, SegmentStart, s, nil), newExactEvent(s.p2, SegmentEnd, s, nil))
+	}
+	heap.Init(&eq)
+
+	comp := &exactComparator{}
+	status := &exactStatus{tree: newRBTree(comp.compare), comparator: comp}
+
+	var starts, through, after []*exactSegment
+	var split [2][]*exactSegment // Scratch space for countedExactPairs.
+	var pairs [][2]*exactSegment
+	for eq.Len() > 0 && !cfg.beyondExact(eq[0].point) {
+		event := heap.Pop(&eq).(exactEvent)
+		first, p := event, event.point
+
+		// 1. Drain every event located at this point.
+		starts = starts[:0]
+		drained := 0
+		for {
+			switch event.typ {
+			case SegmentStart:
+				starts = append(starts, event.seg)
+			case Intersection:
+				// Both segments pass through their crossing, which need not
+				// be tested again.
+				event.seg.through(p)
+				event.other.through(p)
+			}
+			if cfg.observer != nil {
+				cfg.observer.Event(p.float(), event.typ, exactIndexOf(event.seg), exactIndexOf(event.other))
+			}
+			drained++
+			if eq.Len() == 0 || !eq[0].samePoint(&first) {
+				break
+			}
+			event = heap.Pop(&eq).(exactEvent)
+		}
+		if cfg.budget != nil && !cfg.budget.spend(drained) {
+			return
+		}
+
+		// 2. Find the run of segments passing through p.
+		comp.setEvent(&first)
+		var below, above *rbNode[*exactSegment]
+		through, below, above = status.collectAt(p, through[:0])
+		active := len(through)
+		through = append(through, starts...)
+
+		// 3. Count the intersecting pairs at this point, if the sweep owns it.
+		if len(through) > 1 && cfg.ownsExact(p) {
+			var more bool
+			pairs, more = countedExactPairs(through, p, cfg, pairs[:0], &split)
+			if len(pairs) > 0 {
+				m := exactMeeting{kind: PointIntersection, point: p, segs: through, pairs: pairs}
+				if !visit(&m) {
+					return
+				}
+			}
+			if !more {
+				return
+			}
+			if cfg.overlaps && !visitExactOverlaps(through, p, cfg, visit) {
+				return
+			}
+		}
+
+		// 4. Move the status past p, reversing the run in place.
+		after = status.passAt(p, active, below, above, starts, after[:0])
+		if cfg.observer != nil {
+			observeExactPass(cfg.observer, p, through, active, after, status)
+		}
+
+		// 5. Check the new neighbors for intersections ahead of the sweep.
+		lower, upper := keyOf(below), keyOf(above)
+		if len(after) == 0 {
+			checkExactIntersection(lower, upper, p, &eq, cfg)
+		} else {
+			checkExactIntersection(lower, after[0], p, &eq, cfg)
+			checkExactIntersection(after[len(after)-1], upper, p, &eq, cfg)
+		}
+	}
+}
+
+// countedExactPairs is countedPairs for the exact sweep. Every segment in the
+// run passes through p, so non-parallel pairs meet there, and so does any
+// pair including a zero-length segment.
+func countedExactPairs(through []*exactSegment, p exactPoint, cfg config, buf [][2]*exactSegment, split *[2][]*exactSegment) ([][2]*exactSegment, bool) {
+	inner, ends := split[0][:0], split[1][:0]
+	for _, s := range through {
+		if s.hasEndpoint(p) {
+			ends = append(ends, s)
+		} else {
+			inner = append(inner, s)
+		}
+	}
+	split[0], split[1] = inner, ends
+
+	more := candidatePairs(cfg.endpoints, inner, ends, func(a, b *exactSegment) bool {
+		counted := !cfg.crossLayers || a.layer != b.layer
+		if counted {
+			meet := turn(a, b) != 0 || a.degenerate() || b.degenerate()
+			counted = meet && cfg.counts(a.hasEndpoint(p), b.hasEndpoint(p))
+		}
+		if counted {
+			buf = append(buf, [2]*exactSegment{a, b})
+		}
+		return cfg.budget == nil || cfg.budget.test(counted)
+	})
+	return buf, more
+}
+
+// visitExactOverlaps reports every collinear overlap that starts at p among
+// the segments passing through it, which the sweep owns.
+func visitExactOverlaps(through []*exactSegment, p exactPoint, cfg config, visit func(m *exactMeeting) bool) bool {
+	for i := range through {
+		for j := i + 1; j < len(through); j++ {
+			a, b := through[i], through[j]
+			// Both segments pass through p, so parallel segments are collinear.
+			end, overlap := a.p2, false
+			if (!cfg.crossLayers || a.layer != b.layer) && turn(a, b) == 0 && (a.p1.cmp(p) == 0 || b.p1.cmp(p) == 0) {
+				if b.p2.cmp(a.p2) < 0 {
+					end = b.p2
+				}
+				// Segments ending at p only touch end to end.
+				overlap = end.cmp(p) != 0
+			}
+			if overlap {
+				m := exactMeeting{kind: CollinearOverlap, point: p, end: end, segs: []*exactSegment{a, b}, pairs: [][2]*exactSegment{{a, b}}}
+				if !visit(&m) {
+					return false
+				}
+			}
+			if cfg.budget != nil && !cfg.budget.test(overlap) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// checkExactIntersection pushes an event for the meeting point of s1 and s2 if
+// it lies ahead of the sweep.
+func checkExactIntersection(s1, s2 *exactSegment, current exactPoint, eq *exactQueue, cfg config) {
+	if s1 == nil || s2 == nil {
+		return
+	}
+	result := CheckDisjoint
+	p, ok := exactIntersection(s1, s2)
+	if ok {
+		result = CheckNotInFuture
+		if p.cmp(current) > 0 {
+			heap.Push(eq, newExactEvent(p, Intersection, s1, s2))
+			result = CheckScheduled
+		}
+	}
+	if cfg.observer != nil {
+		var q Point
+		if ok {
+			q = p.float()
+		}
+		cfg.observer.Check(current.float(), s1.index, s2.index, q, result)
+	}
+}
@@ -17,17 +17,69 @@ type Segment struct {
 
 	// Pre-calculated fields to speed up `getY` calculations.
 	slope      float64
-	yIntercept float64
+	length     float64
 	isVertical bool
+
+	// index is the position of the segment in the caller's input slice. It
+	// survives normalization and is used to report results and to order
+	// collinear segments deterministically.
+	index int
+	// layer is the input set the segment belongs to, such as red or blue when
+	// counting intersections between two sets. It is 0 for plain input.
+	layer int
+}
+
+// near reports whether two points coincide within the tolerance eps.
+func near(a, b Point, eps float64) bool {
+	return math.Abs(a.X-b.X) <= eps && math.Abs(a.Y-b.Y) <= eps
+}
+
+// hasEndpoint reports whether p is one of the segment's endpoints.
+func (s *Segment) hasEndpoint(p Point, eps float64) bool {
+	return near(s.P1, p, eps) || near(s.P2, p, eps)
+}
+
+// contains reports whether p lies on the segment: within eps of its
+// supporting line and between its endpoints. Measuring the perpendicular
+// distance keeps the test accurate for steep segments.
+func (s *Segment) contains(p Point, eps float64) bool {
+	if s.length < eps {
+		return near(s.P1, p, eps)
+	}
+	dx, dy := s.P2.X-s.P1.X, s.P2.Y-s.P1.Y
+	qx, qy := p.X-s.P1.X, p.Y-s.P1.Y
+	if math.Abs(dx*qy-dy*qx) > eps*s.length {
+		return false
+	}
+	t := dx*qx + dy*qy // Projection of p onto the segment, scaled by length.
+	return t >= -eps*s.length && t <= s.length*(s.length+eps)
+}
+
+// parallel reports whether two segments have parallel (or collinear)
+// directions, in which case they cannot cross at a single point. In robust
+// mode the test is exact; otherwise it allows for the tolerance.
+func (c config) parallel(a, b *Segment) bool {
+	if c.robust {
+		return cross(a.P1, a.P2, b.P1, b.P2) == 0
+	}
+	return isParallel(a.P2.X-a.P1.X, a.P2.Y-a.P1.Y, b.P2.X-b.P1.X, b.P2.Y-b.P1.Y, c.eps)
+}
+
+// isParallel reports whether the direction vectors r and s are parallel: over
+// the length of the longer one, the shorter one drifts by at most eps.
+func isParallel(rx, ry, sx, sy, eps float64) bool {
+	rxs := rx*sy - ry*sx
+	return math.Abs(rxs) <= eps*math.Max(math.Hypot(rx, ry), math.Hypot(sx, sy))
 }
 
 // intersection calculates the intersection point of two line segments, s1 and s2.
 // It uses a standard vector cross-product method to solve for the intersection.
 //
 // The method returns the intersection Point and a boolean `true` if the segments
-// intersect within their finite bounds. If they are parallel, collinear, or
-// intersect outside their bounds, it returns a zero Point and `false`.
-func (s1 Segment) intersection(s2 Segment) (Point, bool) {
+// intersect within their finite bounds, allowing for the tolerance of cfg. If
+// they are parallel, collinear, or intersect outside their bounds, it returns a
+// zero Point and `false`.
+func (s1 Segment) intersection(s2 Segment, cfg config) (Point, bool) {
 	p1, p2 := s1.P1, s1.P2
 	p3, p4 := s2.P1, s2.P2
 
@@ -35,12 +87,13 @@ func (s1 Segment) intersection(s2 Segment) (Point, bool) {
 	r := Point{X: p2.X - p1.X, Y: p2.Y - p1.Y}
 	s := Point{X: p4.X - p3.X, Y: p4.Y - p3.Y}
 
-	// rxs is the cross product of the direction vectors. If it's zero, the
-	// lines are parallel or collinear and do not intersect in a single point.
-	rxs := r.X*s.Y - r.Y*s.X
-	if math.Abs(rxs) < epsilon {
+	// If the direction vectors are parallel, the lines are parallel or collinear
+	// and do not intersect in a single point.
+	if cfg.parallel(&s1, &s2) {
 		return Point{}, false
 	}
+	// rxs is the cross product of the direction vectors.
+	rxs := r.X*s.Y - r.Y*s.X
 
 	// qp is the vector from the start of s1 to the start of s2.
 	qp := Point{X: p3.X - p1.X, Y: p3.Y - p1.Y}
@@ -51,11 +104,42 @@ func (s1 Segment) intersection(s2 Segment) (Point, bool) {
 	u := (qp.X*r.Y - qp.Y*r.X) / rxs
 
 	// An intersection exists only if both t and u are between 0 and 1,
-	// meaning the intersection point lies on both finite segments.
-	if (t >= -epsilon && t <= 1+epsilon) && (u >= -epsilon && u <= 1+epsilon) {
+	// meaning the intersection point lies on both finite segments. The
+	// tolerance is a distance, so it is scaled to each parameter.
+	eps := cfg.eps
+	tEps := eps / math.Hypot(r.X, r.Y)
+	uEps := eps / math.Hypot(s.X, s.Y)
+	if (t >= -tEps && t <= 1+tEps) && (u >= -uEps && u <= 1+uEps) {
 		intersectionPoint := Point{X: p1.X + t*r.X, Y: p1.Y + t*r.Y}
 		return intersectionPoint, true
 	}
 
 	return Point{}, false
 }
+
+// touch decides whether a and b meet when at least one of them has zero
+// length, and whether the meeting point is an endpoint of a (endA) or of b
+// (endB). The point of a zero-length segment is never its endpoint.
+func (c config) touch(a, b *Segment) (meet, endA, endB bool) {
+	if !c.zeroLength(a) {
+		meet, endB, endA = c.touch(b, a)
+		return meet, endA, endB
+	}
+	q := a.P1
+	if c.zeroLength(b) {
+		if c.robust {
+			return q == b.P1, false, false
+		}
+		return near(q, b.P1, c.eps), false, false
+	}
+	if c.robust {
+		on := orient2d(b.P1, b.P2, q) == 0 &&
+			q.X >= min(b.P1.X, b.P2.X) && q.X <= max(b.P1.X, b.P2.X) &&
+			q.Y >= min(b.P1.Y, b.P2.Y) && q.Y <= max(b.P1.Y, b.P2.Y)
+		return on, false, q == b.P1 || q == b.P2
+	}
+	// The length is only pre-computed for segments in the sweep.
+	probe := *b
+	probe.length = math.Hypot(b.P2.X-b.P1.X, b.P2.Y-b.P1.Y)
+	return probe.contains(q, c.eps), false, b.hasEndpoint(q, c.eps)
+}
@@ -1,5 +1,3 @@
 module github.com/GregoryKogan/benott
 
 go 1.24.2
-
-require github.com/emirpasic/gods v1.18.1
@@ -0,0 +1,56 @@
+package benott
+
+// CountIntersectionsByLayer counts the intersecting pairs within and between
+// labelled layers of segments in a single sweep. Every segment is tagged with
+// the index of its layer, and each intersecting pair is tallied under the
+// layers of its two segments. In the returned matrix, entry [i][j] is the
+// number of pairs with one segment from layer i and the other from layer j,
+// so the matrix is symmetric, and the diagonal holds the pairs within each
+// layer. The entries on and above the diagonal sum to CountIntersections of
+// all layers merged.
+func CountIntersectionsByLayer(layers [][]Segment) [][]int {
+	return CountIntersectionsByLayerWithOptions(layers, Options{})
+}
+
+// CountIntersectionsByLayerWithOptions is CountIntersectionsByLayer with
+// configurable Options. When overlaps are reported, each overlapping pair is
+// tallied like an intersection.
+func CountIntersectionsByLayerWithOptions(layers [][]Segment, opts Options) [][]int {
+	counts := make([][]int, len(layers))
+	for i := range counts {
+		counts[i] = make([]int, len(layers))
+	}
+	sweepLayers(layers, opts, false, func(m *meeting) bool {
+		for _, pair := range m.pairs {
+			a, b := pair[0].layer, pair[1].layer
+			counts[a][b]++
+			if a != b {
+				counts[b][a]++
+			}
+		}
+		return true
+	})
+	return counts
+}
+
+// sweepLayers sweeps all layers together, tagging every segment with the index
+// of its layer. In the combined input the layers follow each other, so a
+// segment's index is offset by the total length of the layers before it. With
+// crossLayers set, only pairs from different layers are visited.
+func sweepLayers(layers [][]Segment, opts Options, crossLayers bool, visit func(m *meeting) bool) {
+	total := 0
+	for _, layer := range layers {
+		total += len(layer)
+	}
+	segments := make([]Segment, 0, total)
+	for i, layer := range layers {
+		start := len(segments)
+		segments = append(segments, layer...)
+		for j := start; j < len(segments); j++ {
+			segments[j].layer = i
+		}
+	}
+	cfg := opts.resolve(segments)
+	cfg.crossLayers = crossLayers
+	sweep(segments, cfg, visit)
+}
@@ -1,5 +1,7 @@
 package benott
 
+import "math"
+
 // CountIntersectionsNaive calculates the number of intersections using a simple
 // O(n^2) brute-force algorithm. It checks every pair of segments for intersections.
 //
@@ -7,19 +9,38 @@ package benott
 // the results of the more complex Bentley-Ottmann implementation. While robust,
 // it is significantly slower for large numbers of segments.
 func CountIntersectionsNaive(segments []Segment) int {
+	return CountIntersectionsNaiveWithOptions(segments, Options{})
+}
+
+// CountIntersectionsNaiveWithOptions is CountIntersectionsNaive with the same
+// tolerance, endpoint policy and overlap reporting as
+// CountIntersectionsWithOptions. Both give the same answer for the same
+// Options when every intersection, endpoint and segment in the input is either
+// exactly where another one is or well separated from it relative to Epsilon.
+// When three or more segments nearly meet within Epsilon, each algorithm
+// applies the tolerance to a different set of pairs, and their counts may
+// differ; with Robust set, both decide every test exactly and always agree.
+func CountIntersectionsNaiveWithOptions(segments []Segment, opts Options) int {
+	cfg := opts.resolve(segments)
 	count := 0
 	for i := range segments {
 		for j := i + 1; j < len(segments); j++ {
-			s1 := segments[i]
-			s2 := segments[j]
-
-			// Don't count intersections at shared endpoints as a true "crossing".
-			if segmentsShareEndpoint(&s1, &s2) {
+			s1 := &segments[i]
+			s2 := &segments[j]
+			if cfg.skips(s1) || cfg.skips(s2) {
 				continue
 			}
 
-			// Check for a proper intersection using the CCW test.
-			if segmentsIntersectCCW(s1.P1, s1.P2, s2.P1, s2.P2) {
+			// Check whether the segments meet using the CCW test, or as a
+			// point on a segment if either has zero length, then let the
+			// endpoint policy decide whether the meeting counts.
+			meet, end1, end2 := segmentsMeetCCW(s1, s2, cfg)
+			if cfg.zeroLength(s1) || cfg.zeroLength(s2) {
+				meet, end1, end2 = cfg.touch(s1, s2)
+			}
+			if meet && cfg.counts(end1, end2) {
+				count++
+			} else if cfg.overlaps && segmentsOverlap(s1, s2, cfg) {
 				count++
 			}
 		}
@@ -27,35 +48,99 @@ func CountIntersectionsNaive(segments []Segment) int {
 	return count
 }
 
-// segmentsShareEndpoint checks if two segments share a common vertex.
-// Direct struct comparison works here because Point structs are comparable.
-func segmentsShareEndpoint(s1, s2 *Segment) bool {
-	return s1.P1 == s2.P1 || s1.P1 == s2.P2 || s1.P2 == s2.P1 || s1.P2 == s2.P2
-}
-
-// segmentsIntersectCCW tests for segment intersection using the counter-clockwise
+// segmentsMeetCCW tests for segment intersection using the counter-clockwise
 // (CCW) orientation test. This is a standard geometric primitive that avoids
-// floating-point division and is highly robust.
+// computing the intersection point and is highly robust.
 //
-// It returns true if the segment (p1, p2) and the segment (p3, p4) have a
-// "proper" intersection, meaning they cross each other at a single point.
-func segmentsIntersectCCW(p1, p2, p3, p4 Point) bool {
-	// This internal helper function determines the orientation of the ordered
-	// triplet (u, v, w). The logic is adapted directly from your provided code.
-	ccw := func(u, v, w Point) bool {
-		// The formula calculates a value proportional to the signed area of the
-		// triangle (u,v,w). The sign indicates whether the turn from vector
-		// uv to vw is clockwise or counter-clockwise.
-		return (w.Y-u.Y)*(v.X-u.X) > (v.Y-u.Y)*(w.X-u.X)
-	}
-
-	// The segments intersect if and only if the endpoints of each segment are
-	// on opposite sides of the line containing the other segment.
-	//
-	// Check if p3 and p4 are on opposite sides of the line defined by p1 and p2.
-	test1 := ccw(p1, p3, p4) != ccw(p2, p3, p4)
-	// Check if p1 and p2 are on opposite sides of the line defined by p3 and p4.
-	test2 := ccw(p1, p2, p3) != ccw(p1, p2, p4)
-
-	return test1 && test2
+// It reports whether the non-parallel segments s1 and s2 meet at a single
+// point, and whether that point is an endpoint of s1 (end1) or of s2 (end2).
+// Parallel and collinear segments never meet at a single point.
+func segmentsMeetCCW(s1, s2 *Segment, cfg config) (meet, end1, end2 bool) {
+	if cfg.parallel(s1, s2) {
+		return false, false, false
+	}
+
+	// Orientation of the endpoints of each segment relative to the line
+	// containing the other.
+	o1 := cfg.ccw(s1.P1, s1.P2, s2.P1)
+	o2 := cfg.ccw(s1.P1, s1.P2, s2.P2)
+	o3 := cfg.ccw(s2.P1, s2.P2, s1.P1)
+	o4 := cfg.ccw(s2.P1, s2.P2, s1.P2)
+
+	// The segments meet if and only if the endpoints of each segment are not
+	// strictly on the same side of the line containing the other.
+	if o1*o2 > 0 || o3*o4 > 0 {
+		return false, false, false
+	}
+
+	// The lines meet at a single point, so an endpoint lying on the other line
+	// is that point.
+	return true, o3 == 0 || o4 == 0, o1 == 0 || o2 == 0
+}
+
+// segmentsOverlap reports whether s1 and s2 are collinear and share a
+// sub-segment longer than eps, or of any positive length in robust mode.
+func segmentsOverlap(s1, s2 *Segment, cfg config) bool {
+	if !cfg.parallel(s1, s2) || cfg.ccw(s1.P1, s1.P2, s2.P1) != 0 || cfg.ccw(s1.P1, s1.P2, s2.P2) != 0 {
+		return false
+	}
+	if cfg.robust {
+		// Along their common line, the overlap runs from the later start to
+		// the earlier end.
+		a1, a2 := s1.P1, s1.P2
+		if pointLess(a2, a1) {
+			a1, a2 = a2, a1
+		}
+		b1, b2 := s2.P1, s2.P2
+		if pointLess(b2, b1) {
+			b1, b2 = b2, b1
+		}
+		if pointLess(a1, b1) {
+			a1 = b1
+		}
+		if pointLess(b2, a2) {
+			a2 = b2
+		}
+		return pointLess(a1, a2)
+	}
+	eps := cfg.eps
+
+	// Project both segments onto the direction of s1 and intersect the intervals.
+	dx, dy := s1.P2.X-s1.P1.X, s1.P2.Y-s1.P1.Y
+	length := math.Hypot(dx, dy)
+	if length <= eps {
+		return false
+	}
+	project := func(p Point) float64 { return ((p.X-s1.P1.X)*dx + (p.Y-s1.P1.Y)*dy) / length }
+	lo, hi := project(s2.P1), project(s2.P2)
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return math.Min(length, hi)-math.Max(0, lo) > eps
+}
+
+// ccw determines the orientation of the ordered triplet (u, v, w). It returns 1
+// if the turn from vector uv to vw is counter-clockwise, -1 if it is clockwise,
+// and 0 if w lies on the line through u and v: exactly in robust mode, or
+// within the tolerance otherwise.
+func (c config) ccw(u, v, w Point) int {
+	// The cross product is proportional to the signed area of the triangle
+	// (u,v,w); dividing by |uv| turns it into the signed distance of w from
+	// the line.
+	var det float64
+	if c.robust {
+		det = orient2d(u, v, w)
+	} else {
+		det = (v.X-u.X)*(w.Y-u.Y) - (v.Y-u.Y)*(w.X-u.X)
+		if math.Abs(det) <= c.eps*math.Hypot(v.X-u.X, v.Y-u.Y) {
+			return 0
+		}
+	}
+	switch {
+	case det > 0:
+		return 1
+	case det < 0:
+		return -1
+	}
+	return 0
 }
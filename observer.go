@@ -0,0 +1,145 @@
+package benott
+
+// Observer is notified of each step of a sweep, for tracing, debugging or
+// visualizing it. Set it in Options.Observer.
+//
+// Segments are identified by their index in the input slice, also by the
+// sweeps of CountIntersectionsParallel, which each sweep part of it; red-blue
+// and layered sweeps use the index in their combined input, as described at
+// Options.Observer. Points are those the sweep computes, rounding included.
+// Slices passed to an Observer are reused by the sweep and must not be
+// retained.
+type Observer interface {
+	// Event is called for every event taken from the queue. seg is the
+	// segment starting or ending at p; for an Intersection event, seg and
+	// other are the two segments whose meeting created it. Otherwise other
+	// is -1.
+	Event(p Point, typ EventType, seg, other int)
+	// Add is called when a segment starting at p is added to the sweep-line
+	// status.
+	Add(p Point, seg int)
+	// Remove is called when a segment ending at p is removed from the status.
+	Remove(p Point, seg int)
+	// Check is called whenever two segments neighboring in the status are
+	// tested for an intersection ahead of the event point p. q is their
+	// meeting point, if result is not CheckDisjoint.
+	Check(p Point, a, b int, q Point, result CheckResult)
+	// Block is called at every event point p that at least two segments pass
+	// through. before holds them in the order of the status just before p,
+	// from bottom to top, followed by those starting at p; after holds the
+	// segments continuing past p in their new order.
+	Block(p Point, before, after []int)
+}
+
+// StatusObserver is an Observer that also follows the contents of the
+// sweep-line status. If Options.Observer implements it, the sweep calls Status
+// after every event point, which takes time linear in the size of the status.
+type StatusObserver interface {
+	Observer
+	// Status is called once the sweep has moved past the event point p, with
+	// the segments then in the status, from bottom to top.
+	Status(p Point, segments []int)
+}
+
+// CheckResult is the outcome of testing two neighboring segments for an
+// intersection ahead of the sweep line.
+type CheckResult int
+
+const (
+	// CheckDisjoint means that the segments do not meet.
+	CheckDisjoint CheckResult = iota
+	// CheckScheduled means that the segments meet ahead of the sweep line, and
+	// an Intersection event was queued there. It is also queued where they
+	// meet below the event point in the column being swept, if the sweep went
+	// past that point without swapping them.
+	CheckScheduled
+	// CheckNotInFuture means that the segments meet at or behind the event
+	// point, within the tolerance, so no event was queued: the meeting is
+	// being or has been processed.
+	CheckNotInFuture
+)
+
+// indexOf returns the input index of seg, or -1 if seg is nil.
+func indexOf(seg *Segment) int {
+	if seg == nil {
+		return -1
+	}
+	return seg.index
+}
+
+// exactIndexOf is indexOf for the exact sweep.
+func exactIndexOf(seg *exactSegment) int {
+	if seg == nil {
+		return -1
+	}
+	return seg.index
+}
+
+// observePass tells obs how the sweep moved past p: which segments passed
+// through it, which left and joined the status and, for a StatusObserver, what
+// the status holds now. through holds the active segments of the run followed
+// by those starting at p, and after the new run.
+func (sw *Sweeper) observePass(obs Observer, p Point, through []*Segment, active int, after []*Segment, eps float64) {
+	if len(through) > 1 {
+		before, next := sw.observed[0][:0], sw.observed[1][:0]
+		for _, seg := range through {
+			before = append(before, seg.index)
+		}
+		for _, seg := range after {
+			next = append(next, seg.index)
+		}
+		obs.Block(p, before, next)
+		sw.observed = [2][]int{before, next}
+	}
+	for _, seg := range through[:active] {
+		if near(seg.P2, p, eps) {
+			obs.Remove(p, seg.index)
+		}
+	}
+	for _, seg := range through[active:] {
+		if !near(seg.P2, p, eps) {
+			obs.Add(p, seg.index)
+		}
+	}
+	if obs, ok := obs.(StatusObserver); ok {
+		status := sw.observed[0][:0]
+		for node := sw.status.tree.first; node != nil; node = node.next {
+			status = append(status, node.key.index)
+		}
+		obs.Status(p, status)
+		sw.observed[0] = status
+	}
+}
+
+// observeExactPass is observePass for the exact sweep, with p rounded to the
+// nearest float64 point.
+func observeExactPass(obs Observer, p exactPoint, through []*exactSegment, active int, after []*exactSegment, status *exactStatus) {
+	q := p.float()
+	if len(through) > 1 {
+		before, next := make([]int, len(through)), make([]int, len(after))
+		for i, seg := range through {
+			before[i] = seg.index
+		}
+		for i, seg := range after {
+			next[i] = seg.index
+		}
+		obs.Block(q, before, next)
+	}
+	for _, seg := range through[:active] {
+		if seg.p2.cmp(p) == 0 {
+			obs.Remove(q, seg.index)
+		}
+	}
+	for _, seg := range through[active:] {
+		if seg.p2.cmp(p) != 0 {
+			obs.Add(q, seg.index)
+		}
+	}
+	if obs, ok := obs.(StatusObserver); ok {
+		segments := []int{}
+		for node := status.tree.first; node != nil; node = node.next {
+			segments = append(segments, node.key.index)
+		}
+		obs.Status(q, segments)
+	}
+}
@@ -0,0 +1,143 @@
+package benott
+
+import "math"
+
+// ToleranceMode selects how Options.Epsilon is interpreted.
+type ToleranceMode int
+
+const (
+	// AbsoluteTolerance uses Epsilon as a distance in input coordinates.
+	AbsoluteTolerance ToleranceMode = iota
+	// RelativeTolerance scales Epsilon by the magnitude of the input, namely the
+	// largest absolute coordinate of any endpoint. This suits data whose units
+	// range from millimetres to planetary scales.
+	RelativeTolerance
+)
+
+// EndpointPolicy selects which meetings of two segments count as an
+// intersection when the meeting point is an endpoint of one or both of them.
+// Parallel and collinear segments never intersect at a single point and are
+// not counted under any policy.
+type EndpointPolicy int
+
+const (
+	// IncludeTJunctions counts a meeting unless it is an endpoint of both
+	// segments: proper crossings and T-junctions, where one segment ends on the
+	// interior of another, are counted. This is the default.
+	IncludeTJunctions EndpointPolicy = iota
+	// ProperCrossingsOnly counts only meetings in the interior of both segments.
+	ProperCrossingsOnly
+	// IncludeSharedEndpoints counts every meeting, including segments that only
+	// share an endpoint.
+	IncludeSharedEndpoints
+)
+
+// Options configures the sweep. The zero value reproduces the behavior of
+// CountIntersections.
+type Options struct {
+	// Epsilon is the tolerance used to decide whether two points coincide, a
+	// point lies on a segment, or two segments are parallel. Values that are
+	// zero, negative or NaN select the default of 1e-9.
+	Epsilon float64
+	// Tolerance selects whether Epsilon is absolute or relative.
+	Tolerance ToleranceMode
+	// Endpoints selects whether meetings at segment endpoints are counted.
+	Endpoints EndpointPolicy
+	// ReportOverlaps makes the sweep report collinear segments that share a
+	// sub-segment of positive length. Such pairs are otherwise ignored.
+	// Finding them tests every pair of segments meeting at an event point,
+	// which takes quadratic time where many segments share an endpoint.
+	ReportOverlaps bool
+	// Robust decides every test exactly for the float64 input, instead of
+	// comparing floating-point results against Epsilon. The sweep keeps event
+	// points, computed intersections included, as exact rationals, so event
+	// order, which events coincide and whether a point lies on a segment are
+	// all exact; other tests use adaptive-precision predicates. Epsilon and
+	// Tolerance then have no effect, and reported points are rounded to the
+	// nearest float64. Robust sweeps are about ten times slower and allocate
+	// as they go.
+	Robust bool
+	// ZeroLength selects how segments whose endpoints coincide are handled.
+	ZeroLength ZeroLengthPolicy
+	// MaxIntersections, if positive, stops CountIntersectionsContext with
+	// ErrTooManyIntersections once it has counted more intersections.
+	MaxIntersections int
+	// MaxEvents, if positive, stops CountIntersectionsContext with
+	// ErrTooManyEvents once it has taken more events from its queue. Every
+	// segment contributes two events and every intersection found at least one.
+	MaxEvents int
+	// Observer, if set, is notified of each step of the sweep. Sweeps running
+	// concurrently, like those of CountIntersectionsParallel, notify it
+	// concurrently. Red-blue and layered sweeps identify the segments by their
+	// index in the combined input: the red segments followed by the blue ones,
+	// or all layers one after another. The exact sweeps over integer and rational coordinates do
+	// not use it.
+	Observer Observer
+}
+
+// config is the resolved form of Options used by a single sweep.
+type config struct {
+	// eps is the absolute tolerance, in input coordinates.
+	eps         float64
+	endpoints   EndpointPolicy
+	overlaps    bool
+	robust      bool
+	zeroLengths ZeroLengthPolicy
+	// crossLayers restricts the sweep to pairs of segments from different
+	// layers.
+	crossLayers bool
+	// slab, if set, restricts the sweep to a vertical strip of the plane.
+	slab *slab
+	// budget, if set, limits the work of the sweep.
+	budget   *budget
+	observer Observer
+}
+
+// resolve turns the options into the configuration for sweeping segments.
+func (o Options) resolve(segments []Segment) config {
+	eps := o.Epsilon
+	if !(eps > 0) {
+		eps = epsilon
+	}
+	if o.Tolerance == RelativeTolerance {
+		scale := 0.0
+		for _, s := range segments {
+			if !finite(&s) {
+				continue
+			}
+			scale = math.Max(scale, math.Max(
+				math.Max(math.Abs(s.P1.X), math.Abs(s.P1.Y)),
+				math.Max(math.Abs(s.P2.X), math.Abs(s.P2.Y)),
+			))
+		}
+		// An input made only of the origin has no scale to speak of.
+		if scale > 0 {
+			eps *= scale
+		}
+	}
+	return config{eps: eps, endpoints: o.Endpoints, overlaps: o.ReportOverlaps, robust: o.Robust, zeroLengths: o.ZeroLength, observer: o.Observer}
+}
+
+// counts reports whether a meeting of two non-parallel segments is counted
+// under the endpoint policy, given whether the meeting point is an endpoint of
+// the first segment (endA) and of the second (endB).
+func (c config) counts(endA, endB bool) bool {
+	switch c.endpoints {
+	case ProperCrossingsOnly:
+		return !endA && !endB
+	case IncludeSharedEndpoints:
+		return true
+	default:
+		return !endA || !endB
+	}
+}
+
+// crosses reports whether two segments meeting at p form a counted
+// intersection there.
+func (c config) crosses(a, b *Segment, p Point) bool {
+	if c.zeroLength(a) || c.zeroLength(b) {
+		meet, endA, endB := c.touch(a, b)
+		return meet && c.counts(endA, endB)
+	}
+	return !c.parallel(a, b) && c.counts(a.hasEndpoint(p, c.eps), b.hasEndpoint(p, c.eps))
+}
@@ -0,0 +1,283 @@
+package benott
+
+import (
+	"math"
+	"math/big"
+	"runtime"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// maxSlabSample bounds the number of endpoints sampled to place the slab
+// boundaries.
+const maxSlabSample = 1 << 16
+
+// slab is a vertical strip of the plane, swept on its own by
+// CountIntersectionsParallel. It owns the intersections with lo <= x < hi;
+// the outermost slabs extend to infinity.
+type slab struct {
+	lo, hi float64
+}
+
+// owns reports whether p lies in the slab.
+func (sl *slab) owns(p Point) bool {
+	return p.X >= sl.lo && p.X < sl.hi
+}
+
+// enter returns the point where the normalized segment s enters the slab: its
+// left endpoint, or the point on it at lo if it starts further left. This
+// clips s to the slab without changing its geometry, which the sweep still
+// takes from the endpoints.
+func (sl *slab) enter(s *Segment) Point {
+	switch {
+	case s.P1.X >= sl.lo || s.isVertical:
+		return s.P1
+	case s.P2.X <= sl.lo:
+		return s.P2
+	}
+	return Point{X: sl.lo, Y: s.P1.Y + s.slope*(sl.lo-s.P1.X)}
+}
+
+// beyond reports whether the sweep has passed p's slab, so that events at p
+// need not be processed. A full sweep is never beyond any point.
+func (c config) beyond(p Point) bool {
+	return c.slab != nil && p.X > c.slab.hi+c.eps
+}
+
+// enterExact is enter for the exact sweep.
+func (sl *slab) enterExact(s *exactSegment) exactPoint {
+	switch {
+	case cmpFloat(s.p1.x, sl.lo) >= 0 || s.vertical:
+		return s.p1
+	case cmpFloat(s.p2.x, sl.lo) <= 0:
+		return s.p2
+	}
+	lo := new(big.Rat).SetFloat64(sl.lo)
+	return exactPoint{x: lo, y: s.yAt(lo)}
+}
+
+// beyondExact is beyond for the exact sweep, which has no tolerance.
+func (c config) beyondExact(p exactPoint) bool {
+	return c.slab != nil && cmpFloat(p.x, c.slab.hi) > 0
+}
+
+// ownsExact reports whether the exact sweep counts the intersections at p:
+// always in a full sweep, and only if p lies in the slab otherwise. The exact
+// point is known, so unlike a floating-point sweep the exact one decides this
+// for every meeting itself.
+func (c config) ownsExact(p exactPoint) bool {
+	return c.slab == nil || (cmpFloat(p.x, c.slab.lo) >= 0 && cmpFloat(p.x, c.slab.hi) < 0)
+}
+
+// cmpFloat compares r with f, which may be infinite, like big.Rat.Cmp.
+func cmpFloat(r *big.Rat, f float64) int {
+	if math.IsInf(f, 0) {
+		return -int(math.Copysign(1, f))
+	}
+	return r.Cmp(new(big.Rat).SetFloat64(f))
+}
+
+// CountIntersectionsParallel counts the intersecting pairs like
+// CountIntersections, sweeping on up to workers goroutines. If workers is less
+// than 1, GOMAXPROCS is used.
+//
+// The x-range of the input is divided into vertical slabs holding similar
+// numbers of segment endpoints, and every slab is swept independently. A
+// segment spanning several slabs is clipped to each of them: it enters a slab
+// at its left edge, already in place in the sweep-line status, and the sweep
+// of a slab stops past its right edge. Every intersection is then counted
+// only by the slab containing it, so intersections on a boundary, or found by
+// two neighboring slabs, are counted exactly once.
+//
+// Segments spanning many slabs are swept once for each of them, so the
+// speed-up is best when most segments are short compared with the width of
+// the input.
+func CountIntersectionsParallel(segments []Segment, workers int) int {
+	return CountIntersectionsParallelWithOptions(segments, workers, Options{})
+}
+
+// CountIntersectionsParallelWithOptions is CountIntersectionsParallel with
+// configurable Options.
+func CountIntersectionsParallelWithOptions(segments []Segment, workers int, opts Options) int {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	cfg := opts.resolve(segments)
+	slabs := divide(segments, workers)
+	if len(slabs) == 1 {
+		return CountIntersectionsWithOptions(segments, opts)
+	}
+
+	counts := make([]int, len(slabs))
+	parts, indices := clip(segments, slabs, cfg.eps)
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg := cfg
+			cfg.slab = &slabs[i]
+			if cfg.observer != nil {
+				cfg.observer = remap(cfg.observer, indices[i])
+			}
+			sweep(part, cfg, func(m *meeting) bool {
+				// A robust sweep only reports the meetings its slab owns.
+				for _, pair := range m.pairs {
+					if cfg.robust || cfg.slab.owns(cfg.meetingPoint(m, pair)) {
+						counts[i]++
+					}
+				}
+				return true
+			})
+		}()
+	}
+	wg.Wait()
+
+	intersections := 0
+	for _, count := range counts {
+		intersections += count
+	}
+	return intersections
+}
+
+// divide splits the plane into at most n slabs, with boundaries at quantiles
+// of a sample of the segment endpoints. Fewer slabs are returned if the
+// endpoints share too few x-coordinates.
+func divide(segments []Segment, n int) []slab {
+	step := max(1, len(segments)/(maxSlabSample/2))
+	xs := make([]float64, 0, 2*len(segments)/step+2)
+	for i := 0; i < len(segments); i += step {
+		if finite(&segments[i]) {
+			xs = append(xs, segments[i].P1.X, segments[i].P2.X)
+		}
+	}
+	slices.Sort(xs)
+
+	slabs := []slab{{lo: math.Inf(-1)}}
+	for k := 1; k < n && len(xs) > 0; k++ {
+		x := xs[k*len(xs)/n]
+		if last := &slabs[len(slabs)-1]; x > last.lo {
+			last.hi = x
+			slabs = append(slabs, slab{lo: x})
+		}
+	}
+	slabs[len(slabs)-1].hi = math.Inf(1)
+	return slabs
+}
+
+// clip returns, for every slab, the segments reaching within eps of it, in
+// input order, together with their indices in the input. Keeping the order
+// keeps the relative indices of the segments, which break ties, the same in
+// every slab as in a full sweep.
+func clip(segments []Segment, slabs []slab, eps float64) ([][]Segment, [][]int) {
+	// slabOf returns the index of the slab containing x.
+	slabOf := func(x float64) int {
+		return sort.Search(len(slabs)-1, func(i int) bool { return slabs[i].hi > x })
+	}
+	parts := make([][]Segment, len(slabs))
+	indices := make([][]int, len(slabs))
+	for index, s := range segments {
+		if !finite(&s) {
+			continue // Left out of every sweep.
+		}
+		lo, hi := min(s.P1.X, s.P2.X), max(s.P1.X, s.P2.X)
+		for i := slabOf(lo - eps); i <= slabOf(hi+eps); i++ {
+			parts[i] = append(parts[i], s)
+			indices[i] = append(indices[i], index)
+		}
+	}
+	return parts, indices
+}
+
+// remap returns an Observer passing the steps of a slab's sweep on to obs,
+// with the index of every segment in the slab replaced by its input index
+// from indices. It is a StatusObserver if obs is.
+func remap(obs Observer, indices []int) Observer {
+	r := &remapped{Observer: obs, indices: indices}
+	if status, ok := obs.(StatusObserver); ok {
+		return &remappedStatus{remapped: r, status: status}
+	}
+	return r
+}
+
+// remapped is the Observer returned by remap. Its buffers hold the indices
+// passed to obs, so it must only be used by a single sweep.
+type remapped struct {
+	Observer
+	indices       []int
+	before, after []int
+}
+
+// index returns the input index of the segment at index i in the slab, or -1
+// for -1.
+func (r *remapped) index(i int) int {
+	if i < 0 {
+		return i
+	}
+	return r.indices[i]
+}
+
+// all replaces the indices in the slab with input indices, into buf.
+func (r *remapped) all(buf, segs []int) []int {
+	buf = buf[:0]
+	for _, i := range segs {
+		buf = append(buf, r.index(i))
+	}
+	return buf
+}
+
+func (r *remapped) Event(p Point, typ EventType, seg, other int) {
+	r.Observer.Event(p, typ, r.index(seg), r.index(other))
+}
+
+func (r *remapped) Add(p Point, seg int) { r.Observer.Add(p, r.index(seg)) }
+
+func (r *remapped) Remove(p Point, seg int) { r.Observer.Remove(p, r.index(seg)) }
+
+func (r *remapped) Check(p Point, a, b int, q Point, result CheckResult) {
+	r.Observer.Check(p, r.index(a), r.index(b), q, result)
+}
+
+func (r *remapped) Block(p Point, before, after []int) {
+	r.before, r.after = r.all(r.before, before), r.all(r.after, after)
+	r.Observer.Block(p, r.before, r.after)
+}
+
+// remappedStatus is the StatusObserver returned by remap.
+type remappedStatus struct {
+	*remapped
+	status StatusObserver
+}
+
+func (r *remappedStatus) Status(p Point, segments []int) {
+	r.before = r.all(r.before, segments)
+	r.status.Status(p, r.before)
+}
+
+// meetingPoint returns the point where the pair from m meets, computed from
+// the two segments alone, so that neighboring slabs agree on it whatever
+// events led each of them to the pair. Where that fails, within the tolerance,
+// it falls back to the point of m.
+func (c config) meetingPoint(m *meeting, pair [2]*Segment) Point {
+	a, b := pair[0], pair[1]
+	if b.index < a.index {
+		a, b = b, a
+	}
+	switch {
+	case m.kind == CollinearOverlap:
+		// An overlap starts where the later of the two segments does.
+		if a.P1.X < b.P1.X || (a.P1.X == b.P1.X && a.P1.Y < b.P1.Y) {
+			return b.P1
+		}
+		return a.P1
+	case c.zeroLength(a):
+		return a.P1
+	case c.zeroLength(b):
+		return b.P1
+	}
+	if p, ok := a.intersection(*b, c); ok {
+		return p
+	}
+	return m.point
+}
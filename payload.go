@@ -0,0 +1,86 @@
+package benott
+
+import (
+	"iter"
+	"slices"
+)
+
+// IntersectionOf describes an intersection between caller-defined items, as
+// reported by FindIntersectionsOf. It mirrors IntersectionResult, with the
+// items themselves in place of their indices.
+type IntersectionOf[T any] struct {
+	// Kind tells whether the result is a point or a collinear overlap.
+	Kind IntersectionKind
+	// Point is the location of the intersection. For an overlap it is the
+	// leftmost (then lowest) end of the shared sub-segment.
+	Point Point
+	// Items holds every item whose segment passes through Point, or the two
+	// overlapping items, in the order they appear in the input.
+	Items []T
+	// Overlap is the shared sub-segment of a CollinearOverlap result, ordered
+	// like the sweep. It is zero for point intersections.
+	Overlap [2]Point
+}
+
+// FindIntersectionsOf is FindIntersections for arbitrary items, such as road
+// records or wire nets, that each describe a segment. The seg function
+// extracts the segment from an item; it is called once per item. Every result
+// carries the items themselves, so no index bookkeeping is needed to map
+// intersections back to the caller's data.
+func FindIntersectionsOf[T any](items []T, seg func(T) Segment) []IntersectionOf[T] {
+	return FindIntersectionsOfWithOptions(items, seg, Options{})
+}
+
+// FindIntersectionsOfWithOptions is FindIntersectionsOf with configurable
+// Options.
+func FindIntersectionsOfWithOptions[T any](items []T, seg func(T) Segment, opts Options) []IntersectionOf[T] {
+	return slices.Collect(IntersectionsOfWithOptions(items, seg, opts))
+}
+
+// IntersectionsOf returns an iterator over the same results as
+// FindIntersectionsOf, yielding each one as soon as the sweep finds it.
+func IntersectionsOf[T any](items []T, seg func(T) Segment) iter.Seq[IntersectionOf[T]] {
+	return IntersectionsOfWithOptions(items, seg, Options{})
+}
+
+// IntersectionsOfWithOptions is IntersectionsOf with configurable Options.
+func IntersectionsOfWithOptions[T any](items []T, seg func(T) Segment, opts Options) iter.Seq[IntersectionOf[T]] {
+	return func(yield func(IntersectionOf[T]) bool) {
+		for r := range IntersectionsWithOptions(segmentsOf(items, seg), opts) {
+			result := IntersectionOf[T]{Kind: r.Kind, Point: r.Point, Items: make([]T, len(r.Segments)), Overlap: r.Overlap}
+			for i, index := range r.Segments {
+				result.Items[i] = items[index]
+			}
+			if !yield(result) {
+				return
+			}
+		}
+	}
+}
+
+// IntersectingPairsOf is IntersectingPairs for arbitrary items. Each pair holds
+// the items in input order, and the pairs are sorted by the input position of
+// the first item, then of the second.
+func IntersectingPairsOf[T any](items []T, seg func(T) Segment) [][2]T {
+	return IntersectingPairsOfWithOptions(items, seg, Options{})
+}
+
+// IntersectingPairsOfWithOptions is IntersectingPairsOf with configurable
+// Options.
+func IntersectingPairsOfWithOptions[T any](items []T, seg func(T) Segment, opts Options) [][2]T {
+	indices := IntersectingPairsWithOptions(segmentsOf(items, seg), opts)
+	pairs := make([][2]T, len(indices))
+	for i, pair := range indices {
+		pairs[i] = [2]T{items[pair[0]], items[pair[1]]}
+	}
+	return pairs
+}
+
+// segmentsOf extracts the segment of every item.
+func segmentsOf[T any](items []T, seg func(T) Segment) []Segment {
+	segments := make([]Segment, len(items))
+	for i, item := range items {
+		segments[i] = seg(item)
+	}
+	return segments
+}
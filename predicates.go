@@ -0,0 +1,149 @@
+package benott
+
+import (
+	"math"
+	"math/big"
+)
+
+// This file implements adaptive-precision geometric predicates in the style of
+// Jonathan Shewchuk's "Adaptive Precision Floating-Point Arithmetic and Fast
+// Robust Geometric Predicates". Each predicate first evaluates its determinant
+// in plain float64 arithmetic together with a bound on the rounding error. Only
+// when the result is too close to zero for its sign to be trusted does it fall
+// back to exact arithmetic on floating-point expansions.
+
+// machineEpsilon is half the distance between 1 and the next float64 (2^-53).
+const machineEpsilon = 1.0 / (1 << 53)
+
+// crossErrBound bounds the relative rounding error of the float64 evaluation
+// of a 2x2 determinant whose entries are differences of input coordinates.
+const crossErrBound = (3 + 16*machineEpsilon) * machineEpsilon
+
+// minSafe and maxSafe bound the magnitudes of nonzero coordinates for which
+// the float64 arithmetic of the predicates is exact enough: the products of
+// coordinates, and of their differences, neither overflow nor fall below the
+// normal range, where rounding errors are no longer relative and twoProduct
+// no longer exact.
+const (
+	minSafe = 0x1p-450
+	maxSafe = 0x1p480
+)
+
+// cross returns the cross product of the vectors b-a and d-c. The magnitude
+// is approximate, but the sign is always exact: positive if d-c turns
+// counter-clockwise from b-a, negative if clockwise, and zero if the vectors
+// are parallel.
+func cross(a, b, c, d Point) float64 {
+	if !safe(a, b, c, d) {
+		return crossBig(a, b, c, d)
+	}
+	left := (b.X - a.X) * (d.Y - c.Y)
+	right := (b.Y - a.Y) * (d.X - c.X)
+	det := left - right
+
+	// If the two products have opposite signs, no rounding can flip the sign
+	// of their difference.
+	if (left > 0 && right <= 0) || (left < 0 && right >= 0) {
+		return det
+	}
+	errBound := crossErrBound * math.Abs(left+right)
+	if det > errBound || -det > errBound {
+		return det
+	}
+	return crossExact(a, b, c, d)
+}
+
+// safe reports whether every coordinate of the points is zero or within
+// minSafe and maxSafe in magnitude.
+func safe(a, b, c, d Point) bool {
+	for _, v := range [...]float64{a.X, a.Y, b.X, b.Y, c.X, c.Y, d.X, d.Y} {
+		if v = math.Abs(v); v != 0 && (v < minSafe || v > maxSafe) {
+			return false
+		}
+	}
+	return true
+}
+
+// crossBig returns the sign of the cross product of b-a and d-c, computed
+// exactly with big integers. It is the fallback for coordinates at
+// magnitudes where float64 products overflow or underflow.
+func crossBig(a, b, c, d Point) float64 {
+	v, _ := scaled(a.X, a.Y, b.X, b.Y, c.X, c.Y, d.X, d.Y)
+	sub := func(i, j int) *big.Int { return new(big.Int).Sub(v[i], v[j]) }
+	left := new(big.Int).Mul(sub(2, 0), sub(7, 5))
+	return float64(left.Cmp(new(big.Int).Mul(sub(3, 1), sub(6, 4))))
+}
+
+// sign returns 1, -1 or 0 depending on whether v is positive, negative or
+// zero.
+func sign(v float64) int {
+	switch {
+	case v > 0:
+		return 1
+	case v < 0:
+		return -1
+	}
+	return 0
+}
+
+// orient2d returns a positive value if a, b and c are in counter-clockwise
+// order, a negative value if they are in clockwise order, and zero if they
+// are collinear. The sign is always exact.
+func orient2d(a, b, c Point) float64 { return cross(a, b, a, c) }
+
+// crossExact evaluates the cross product of b-a and d-c exactly, for
+// coordinates accepted by safe. Expanding
+// (b-a)x(d-c) gives eight products of input coordinates, each of which is
+// represented exactly by a two-term expansion; their sum is accumulated into a
+// nonoverlapping expansion whose largest term carries the sign of the result.
+func crossExact(a, b, c, d Point) float64 {
+	var terms [16]float64
+	products := [8][2]float64{
+		{b.X, d.Y}, {-b.X, c.Y}, {-a.X, d.Y}, {a.X, c.Y},
+		{-b.Y, d.X}, {b.Y, c.X}, {a.Y, d.X}, {-a.Y, c.X},
+	}
+	for i, p := range products {
+		terms[2*i], terms[2*i+1] = twoProduct(p[0], p[1])
+	}
+
+	var buf [len(terms) + 1]float64
+	expansion := buf[:0]
+	for _, t := range terms {
+		expansion = growExpansion(expansion, t)
+	}
+
+	// The expansion is ordered by increasing magnitude but may contain zero
+	// terms, so scan for the most significant nonzero one.
+	for i := len(expansion) - 1; i >= 0; i-- {
+		if expansion[i] != 0 {
+			return expansion[i]
+		}
+	}
+	return 0
+}
+
+// twoSum returns s = fl(a+b) and the exact rounding error e, so a+b = s+e.
+func twoSum(a, b float64) (s, e float64) {
+	s = a + b
+	bVirtual := s - a
+	aVirtual := s - bVirtual
+	e = (a - aVirtual) + (b - bVirtual)
+	return s, e
+}
+
+// twoProduct returns p = fl(a*b) and the exact rounding error e, so a*b = p+e.
+func twoProduct(a, b float64) (p, e float64) {
+	p = a * b
+	e = math.FMA(a, b, -p)
+	return p, e
+}
+
+// growExpansion adds b to the nonoverlapping expansion e, returning a new
+// nonoverlapping expansion with the exact sum. The result reuses e's storage.
+func growExpansion(e []float64, b float64) []float64 {
+	q := b
+	for i, term := range e {
+		q, e[i] = twoSum(q, term)
+	}
+	return append(e, q)
+}
@@ -0,0 +1,127 @@
+package benott
+
+import (
+	"iter"
+	"math/big"
+	"slices"
+	"sort"
+)
+
+// RatPoint represents a point with arbitrary-precision rational coordinates.
+// Both coordinates must be non-nil.
+type RatPoint struct {
+	X, Y *big.Rat
+}
+
+// RatSegment represents a line segment between two RatPoints.
+type RatSegment struct {
+	P1, P2 RatPoint
+}
+
+// RatIntersectionResult describes a single intersection found by the rational
+// sweep. It mirrors IntersectionResult, with exact coordinates.
+type RatIntersectionResult struct {
+	// Kind tells whether the result is a point or a collinear overlap.
+	Kind IntersectionKind
+	// Point is the exact location of the intersection. For an overlap it is
+	// the leftmost (then lowest) end of the shared sub-segment.
+	Point RatPoint
+	// Segments holds the indices, in ascending order, of every input segment
+	// passing through Point, or of the two overlapping segments.
+	Segments []int
+	// Overlap is the shared sub-segment of a CollinearOverlap result, ordered
+	// like the sweep. It is zero for point intersections.
+	Overlap [2]RatPoint
+}
+
+// CountIntersectionsRat counts the intersecting pairs in a set of segments with
+// rational coordinates, like CountIntersections, but with exact arithmetic
+// throughout: intersection points are computed as big.Rat, and both the event
+// queue and the sweep-line status order them exactly. The result never depends
+// on a tolerance, which makes this the slowest but provably exact variant.
+func CountIntersectionsRat(segments []RatSegment) int {
+	return CountIntersectionsRatWithOptions(segments, Options{})
+}
+
+// CountIntersectionsRatWithOptions is CountIntersectionsRat with
+// configurable endpoint, overlap and zero-length policies. Epsilon, Tolerance
+// and Robust have no effect, since every test is exact.
+func CountIntersectionsRatWithOptions(segments []RatSegment, opts Options) int {
+	return countExact(ratSegments(segments), opts)
+}
+
+// FindIntersectionsRat runs the same sweep as CountIntersectionsRat and reports
+// every intersection point exactly, together with the indices of all input
+// segments passing through it. Results are ordered from left to right, then
+// from bottom to top.
+func FindIntersectionsRat(segments []RatSegment) []RatIntersectionResult {
+	return FindIntersectionsRatWithOptions(segments, Options{})
+}
+
+// FindIntersectionsRatWithOptions is FindIntersectionsRat with configurable
+// Options. With Options.ReportOverlaps set, collinear overlaps are reported as
+// CollinearOverlap results, at the position of their leftmost end.
+func FindIntersectionsRatWithOptions(segments []RatSegment, opts Options) []RatIntersectionResult {
+	return slices.Collect(intersectionsExact(ratSegments(segments), opts))
+}
+
+// ratSegments converts rational input into segments for the exact sweep.
+func ratSegments(segments []RatSegment) []exactSegment {
+	exact := make([]exactSegment, len(segments))
+	for i, s := range segments {
+		exact[i] = exactSegment{
+			p1: exactPoint{x: s.P1.X, y: s.P1.Y},
+			p2: exactPoint{x: s.P2.X, y: s.P2.Y},
+		}
+		exact[i].init(i)
+	}
+	return exact
+}
+
+// countExact returns the number of intersecting pairs among the initialized
+// segments, counted by the exact sweep.
+func countExact(segments []exactSegment, opts Options) int {
+	intersections := 0
+	exactSweep(segments, opts.exactConfig(), func(m *exactMeeting) bool {
+		intersections += len(m.pairs)
+		return true
+	})
+	return intersections
+}
+
+// intersectionsExact returns an iterator over the results of the exact sweep.
+func intersectionsExact(segments []exactSegment, opts Options) iter.Seq[RatIntersectionResult] {
+	return func(yield func(RatIntersectionResult) bool) {
+		exactSweep(segments, opts.exactConfig(), func(m *exactMeeting) bool {
+			return yield(m.result())
+		})
+	}
+}
+
+// exactConfig returns the configuration for the exact sweeps over integer and
+// rational input, which only use the endpoint, overlap and zero-length
+// policies.
+func (o Options) exactConfig() config {
+	return config{endpoints: o.Endpoints, overlaps: o.ReportOverlaps, zeroLengths: o.ZeroLength}
+}
+
+// result converts the meeting into a RatIntersectionResult owned by the
+// caller.
+func (m *exactMeeting) result() RatIntersectionResult {
+	indices := make([]int, len(m.segs))
+	for i, seg := range m.segs {
+		indices[i] = seg.index
+	}
+	sort.Ints(indices)
+	r := RatIntersectionResult{Kind: m.kind, Point: m.point.ratPoint(), Segments: indices}
+	if m.kind == CollinearOverlap {
+		r.Overlap = [2]RatPoint{r.Point, m.end.ratPoint()}
+	}
+	return r
+}
+
+// ratPoint returns a copy of p that does not share storage with the sweep or
+// its input.
+func (p exactPoint) ratPoint() RatPoint {
+	return RatPoint{X: new(big.Rat).Set(p.x), Y: new(big.Rat).Set(p.y)}
+}
@@ -0,0 +1,84 @@
+package benott
+
+import "sort"
+
+// redLayer is the layer of the red segments in a red-blue sweep.
+const redLayer = 0
+
+// RedBlueResult describes an intersection between two sets of segments, as
+// reported by FindRedBlueIntersections.
+type RedBlueResult struct {
+	// Kind tells whether the result is a point or a collinear overlap.
+	Kind IntersectionKind
+	// Point is the location of the intersection. For an overlap it is the
+	// leftmost (then lowest) end of the shared sub-segment.
+	Point Point
+	// Red and Blue hold the indices, in ascending order, of the segments from
+	// each set passing through Point, or of the two overlapping segments.
+	Red, Blue []int
+	// Overlap is the shared sub-segment of a CollinearOverlap result, ordered
+	// like the sweep. It is zero for point intersections.
+	Overlap [2]Point
+}
+
+// CountRedBlueIntersections counts the intersecting pairs made of one segment
+// from red and one from blue. Crossings within either set are not counted.
+//
+// Both sets are swept together in a single pass. Every segment carries its
+// colour through the event queue and the sweep-line status. Neighbours of the
+// same colour are still tested and their crossings scheduled, since the
+// status must be reordered at them, but only pairs of different colours are
+// counted, without building and filtering the full list of intersections.
+func CountRedBlueIntersections(red, blue []Segment) int {
+	return CountRedBlueIntersectionsWithOptions(red, blue, Options{})
+}
+
+// CountRedBlueIntersectionsWithOptions is CountRedBlueIntersections with
+// configurable Options.
+func CountRedBlueIntersectionsWithOptions(red, blue []Segment, opts Options) int {
+	intersections := 0
+	sweepRedBlue(red, blue, opts, func(m *meeting) bool {
+		intersections += len(m.pairs)
+		return true
+	})
+	return intersections
+}
+
+// FindRedBlueIntersections reports every point where at least one red segment
+// intersects a blue one, together with the segments of each colour passing
+// through it. Results are ordered from left to right, then from bottom to top.
+func FindRedBlueIntersections(red, blue []Segment) []RedBlueResult {
+	return FindRedBlueIntersectionsWithOptions(red, blue, Options{})
+}
+
+// FindRedBlueIntersectionsWithOptions is FindRedBlueIntersections with
+// configurable Options. With Options.ReportOverlaps set, overlaps between a red
+// and a blue segment are reported as CollinearOverlap results.
+func FindRedBlueIntersectionsWithOptions(red, blue []Segment, opts Options) []RedBlueResult {
+	var results []RedBlueResult
+	sweepRedBlue(red, blue, opts, func(m *meeting) bool {
+		r := RedBlueResult{Kind: m.kind, Point: m.point}
+		for _, seg := range m.segs {
+			if seg.layer == redLayer {
+				r.Red = append(r.Red, seg.index)
+			} else {
+				r.Blue = append(r.Blue, seg.index-len(red))
+			}
+		}
+		sort.Ints(r.Red)
+		sort.Ints(r.Blue)
+		if m.kind == CollinearOverlap {
+			r.Overlap = [2]Point{m.point, m.end}
+		}
+		results = append(results, r)
+		return true
+	})
+	return results
+}
+
+// sweepRedBlue sweeps red and blue together, visiting only intersections
+// between the two. In the combined input the blue segments follow the red
+// ones, so a blue segment's index is offset by len(red).
+func sweepRedBlue(red, blue []Segment, opts Options, visit func(m *meeting) bool) {
+	sweepLayers([][]Segment{red, blue}, opts, true, visit)
+}
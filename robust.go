@@ -0,0 +1,63 @@
+package benott
+
+import "math/big"
+
+// sweepRobust runs the exact sweep over segments for a sweep in robust mode,
+// and calls visit for every intersection it finds, like Sweeper.sweep. Event
+// points are kept as exact rationals, so that event order, merging and
+// whether a point lies on a segment are decided exactly; reported points are
+// rounded to the nearest float64. Segments with a NaN or infinite coordinate
+// have no exact value and are left out, as in every sweep.
+func (sw *Sweeper) sweepRobust(segments []Segment, cfg config, visit func(m *meeting) bool) {
+	sw.segments = append(sw.segments[:0], segments...)
+	exact := make([]exactSegment, 0, len(segments))
+	for i := range sw.segments {
+		s := &sw.segments[i]
+		s.index = i
+		if !finite(s) {
+			continue
+		}
+		exact = append(exact, exactSegment{
+			p1:    exactFloat(s.P1),
+			p2:    exactFloat(s.P2),
+			float: true,
+			f1:    s.P1,
+			f2:    s.P2,
+			layer: s.layer,
+		})
+		exact[len(exact)-1].init(i)
+	}
+
+	// The meeting passed to visit refers to the Sweeper's copies of the
+	// segments, which carry the same indices and layers.
+	segs, pairs := sw.through[:0], sw.pairs[:0]
+	defer func() {
+		sw.through, sw.pairs = segs, pairs
+	}()
+	exactSweep(exact, cfg, func(m *exactMeeting) bool {
+		segs, pairs = segs[:0], pairs[:0]
+		for _, seg := range m.segs {
+			segs = append(segs, &sw.segments[seg.index])
+		}
+		for _, pair := range m.pairs {
+			pairs = append(pairs, [2]*Segment{&sw.segments[pair[0].index], &sw.segments[pair[1].index]})
+		}
+		sw.meeting = meeting{kind: m.kind, point: m.point.float(), segs: segs, pairs: pairs}
+		if m.kind == CollinearOverlap {
+			sw.meeting.end = m.end.float()
+		}
+		return visit(&sw.meeting)
+	})
+}
+
+// exactFloat returns the exact value of a point with finite coordinates.
+func exactFloat(p Point) exactPoint {
+	return exactPoint{x: new(big.Rat).SetFloat64(p.X), y: new(big.Rat).SetFloat64(p.Y)}
+}
+
+// float returns p rounded to the nearest float64 coordinates.
+func (p exactPoint) float() Point {
+	x, _ := p.x.Float64()
+	y, _ := p.y.Float64()
+	return Point{X: x, Y: y}
+}
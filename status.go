@@ -1,64 +1,77 @@
 package benott
 
-import (
-	"math"
-
-	rbt "github.com/emirpasic/gods/trees/redblacktree"
-)
+import "math"
 
 // sweepLineComparator provides the dynamic comparison logic for the Red-Black Tree.
 // The ordering of segments in the sweep-line status depends on their y-coordinate
 // at the current x-position of the sweep line. This struct holds that `currentX`
 // state, allowing the comparator to function correctly at each event point.
 type sweepLineComparator struct {
+	// eps is the tolerance used to decide whether segments meet.
+	eps      float64
 	currentX float64
+	// currentY is the y-coordinate of the event being processed. Vertical
+	// segments are placed at this height, and segments that meet below it have
+	// already been reordered while those meeting above it have not.
+	currentY float64
 }
 
 // getY calculates the y-coordinate of a segment at the comparator's currentX.
 func (c *sweepLineComparator) getY(seg *Segment) float64 {
-	// Handle pre-calculated vertical segments.
+	// A vertical segment lies along the sweep line; it is placed at the current
+	// event, clamped to its own extent.
 	if seg.isVertical {
-		return seg.P1.Y
+		return math.Max(seg.P1.Y, math.Min(c.currentY, seg.P2.Y))
 	}
 	// Linear interpolation: y = y1 + (x - x1) * (y2 - y1) / (x2 - x1)
-	//  Use the much faster y = mx + b formula.
-	return seg.slope*c.currentX + seg.yIntercept
+	// Interpolating from P1 rather than using y = mx + b avoids cancellation
+	// on steep segments.
+	return seg.P1.Y + seg.slope*(c.currentX-seg.P1.X)
 }
 
-// Compare implements the github.com/emirpasic/gods/utils.Comparator interface.
-// It compares two segments based on their y-coordinates at the current sweep-line
-// position. If y-coordinates are equal, it uses the segment's slope as a tie-breaker
-// to ensure a consistent and stable ordering.
-func (c *sweepLineComparator) Compare(a, b any) int {
-	segA := a.(*Segment)
-	segB := b.(*Segment)
+// compare compares two segments based on their y-coordinates at the current
+// sweep-line position. If y-coordinates are equal, it uses the segment's slope
+// as a tie-breaker to ensure a consistent and stable ordering.
+func (c *sweepLineComparator) compare(segA, segB *Segment) int {
+	// Segments passing through the current event point meet there, and are
+	// ordered as they will be just after it.
+	p := Point{X: c.currentX, Y: c.currentY}
+	if segA.contains(p, c.eps) && segB.contains(p, c.eps) {
+		return compareMeeting(segA, segB, false)
+	}
+
 	yA := c.getY(segA)
 	yB := c.getY(segB)
 
-	if math.Abs(yA-yB) > epsilon {
+	if math.Abs(yA-yB) > c.eps {
 		if yA < yB {
 			return -1
 		}
 		return 1
 	}
 
-	// Tie-breaking with slope handles collinear segments and ensures stable ordering.
-	var slopeA, slopeB float64
-	if math.Abs(segA.P2.X-segA.P1.X) < epsilon {
-		slopeA = math.Inf(1) // Vertical slope is infinite
-	} else {
-		slopeA = (segA.P2.Y - segA.P1.Y) / (segA.P2.X - segA.P1.X)
-	}
-	if math.Abs(segB.P2.X-segB.P1.X) < epsilon {
-		slopeB = math.Inf(1)
-	} else {
-		slopeB = (segB.P2.Y - segB.P1.Y) / (segB.P2.X - segB.P1.X)
-	}
+	// The segments meet elsewhere on the sweep line. Meetings below the current
+	// event have already been processed, so those segments are in their
+	// post-crossing order; meetings above it are still ahead of the sweep.
+	return compareMeeting(segA, segB, yA > c.currentY)
+}
 
-	if slopeA < slopeB {
+// compareMeeting orders two segments that meet on the sweep line, using the
+// slope as a tie-breaker. To the right of the meeting point the segment with
+// the smaller slope is lower, to the left (before) it is higher. Vertical
+// segments have an infinite slope. Collinear segments keep their input order,
+// which also keeps overlapping segments adjacent in the status.
+func compareMeeting(segA, segB *Segment, before bool) int {
+	if segA.slope != segB.slope {
+		if (segA.slope < segB.slope) != before {
+			return -1
+		}
+		return 1
+	}
+	if segA.index < segB.index {
 		return -1
 	}
-	if slopeA > slopeB {
+	if segA.index > segB.index {
 		return 1
 	}
 	return 0
@@ -67,18 +80,24 @@ func (c *sweepLineComparator) Compare(a, b any) int {
 // Status represents the sweep-line status structure. It maintains the set of
 // segments that are currently intersecting the sweep line, ordered vertically.
 //
-// It is implemented using a Red-Black Tree to achieve efficient O(log n)
-// for add, remove, and neighbor-finding operations.
+// It is implemented using a Red-Black Tree to achieve efficient O(log n) add
+// and remove operations. Its nodes are linked to their neighbors, so stepping
+// through the status in order takes O(1) per segment, and segments crossing
+// at an event point swap places in it without being removed and re-added.
 type Status struct {
-	tree       *rbt.Tree
+	tree       *rbTree[*Segment]
 	comparator *sweepLineComparator
 }
 
 // NewStatus creates and initializes a new Status structure.
-func NewStatus() *Status {
-	comp := &sweepLineComparator{}
+func NewStatus() *Status { return newStatus(config{eps: epsilon}) }
+
+// newStatus creates a Status whose comparator uses the tolerance and
+// predicates of cfg.
+func newStatus(cfg config) *Status {
+	comp := &sweepLineComparator{eps: cfg.eps}
 	return &Status{
-		tree:       rbt.NewWith(comp.Compare),
+		tree:       newRBTree(comp.compare),
 		comparator: comp,
 	}
 }
@@ -88,60 +107,79 @@ func NewStatus() *Status {
 // event point to ensure segments are compared correctly.
 func (s *Status) SetX(x float64) { s.comparator.currentX = x }
 
+// setEvent moves the sweep line to the event point p.
+func (s *Status) setEvent(p Point) {
+	s.comparator.currentX = p.X
+	s.comparator.currentY = p.Y
+}
+
 // Add inserts a segment into the status tree.
-func (s *Status) Add(seg *Segment) { s.tree.Put(seg, true) }
+func (s *Status) Add(seg *Segment) { s.tree.insert(seg) }
 
 // Remove deletes a segment from the status tree.
-func (s *Status) Remove(seg *Segment) { s.tree.Remove(seg) }
-
-// findSuccessor finds the in-order successor of a node in the tree (the next largest element).
-func findSuccessor(node *rbt.Node) *rbt.Node {
-	if node.Right != nil {
-		curr := node.Right
-		for curr.Left != nil {
-			curr = curr.Left
-		}
-		return curr
+func (s *Status) Remove(seg *Segment) { s.tree.remove(seg) }
+
+// FindNeighbors finds the segments immediately above and below a given segment
+// in the status tree. It returns `nil` for a neighbor if one does not exist.
+func (s *Status) FindNeighbors(seg *Segment) (above, below *Segment) {
+	node := s.tree.find(seg)
+	if node == nil {
+		return nil, nil
+	}
+	if node.prev != nil {
+		below = node.prev.key
 	}
-	p := node.Parent
-	curr := node
-	for p != nil && curr == p.Right {
-		curr = p
-		p = p.Parent
+	if node.next != nil {
+		above = node.next.key
 	}
-	return p
+	return above, below
 }
 
-// findPredecessor finds the in-order predecessor of a node in the tree (the next smallest element).
-func findPredecessor(node *rbt.Node) *rbt.Node {
-	if node.Left != nil {
-		curr := node.Left
-		for curr.Right != nil {
-			curr = curr.Right
+// collectAt appends to buf, from bottom to top, every segment in the status
+// that passes through p. It also returns the nodes immediately below and above
+// that run, or `nil` where none exists.
+func (s *Status) collectAt(p Point, buf []*Segment) (run []*Segment, below, above *rbNode[*Segment]) {
+	// Find the lowest node passing through or above p.
+	var first *rbNode[*Segment]
+	for node := s.tree.root; node != s.tree.leaf; {
+		if seg := node.key; seg.contains(p, s.comparator.eps) || s.comparator.getY(seg) > p.Y {
+			first, node = node, node.left
+		} else {
+			node = node.right
 		}
-		return curr
 	}
-	p := node.Parent
-	curr := node
-	for p != nil && curr == p.Left {
-		curr = p
-		p = p.Parent
+
+	below = s.tree.last
+	if first != nil {
+		below = first.prev
 	}
-	return p
-}
 
-// FindNeighbors finds the segments immediately above and below a given segment
-// in the status tree. It returns `nil` for a neighbor if one does not exist.
-func (s *Status) FindNeighbors(seg *Segment) (above, below *Segment) {
-	node := s.tree.GetNode(seg)
-	if node == nil {
-		return nil, nil
+	run = buf
+	above = first
+	for above != nil && above.key.contains(p, s.comparator.eps) {
+		run = append(run, above.key)
+		above = above.next
 	}
-	if predNode := findPredecessor(node); predNode != nil {
-		below = predNode.Key.(*Segment)
+	return run, below, above
+}
+
+// passAt moves the status past p, where collectAt found a run of n segments
+// between the nodes below and above. Segments of the run ending at p are
+// removed and the others, which cross at p, are reversed in place; then the
+// segments in starts are added, unless they end at p as well. It appends the
+// new run, from bottom to top, to buf.
+func (s *Status) passAt(p Point, n int, below, above *rbNode[*Segment], starts, buf []*Segment) []*Segment {
+	eps := s.comparator.eps
+	s.tree.pass(s.tree.next(below), n,
+		func(seg *Segment) bool { return near(seg.P2, p, eps) },
+		func(a, b *Segment) int { return compareMeeting(a, b, false) })
+	for _, seg := range starts {
+		if !near(seg.P2, p, eps) {
+			s.tree.insert(seg)
+		}
 	}
-	if succNode := findSuccessor(node); succNode != nil {
-		above = succNode.Key.(*Segment)
+	for node := s.tree.next(below); node != above; node = node.next {
+		buf = append(buf, node.key)
 	}
-	return above, below
+	return buf
 }
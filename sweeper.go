@@ -0,0 +1,101 @@
+package benott
+
+// Sweeper runs repeated sweeps over small inputs without repeated allocation.
+// It keeps its event storage, status tree nodes, segment copies and scratch
+// buffers between calls, so once it has warmed up to the size of its inputs, a
+// call allocates nothing. Sweeps in robust mode, which compute with exact
+// rationals, still allocate as they go.
+//
+// A Sweeper is configured with Options when it is created or Reset. It is not
+// safe for concurrent use; use one Sweeper per goroutine.
+type Sweeper struct {
+	opts Options
+
+	queue    EventQueue
+	column   column
+	free     []*Event // Events ready for reuse.
+	segments []Segment
+	status   *Status
+
+	starts, through, after []*Segment
+	pairs                  [][2]*Segment
+	// inner and ends hold positions in through, split by countedPairs.
+	inner, ends []int
+
+	// meeting and overlap hold the intersection passed to the visitor, so
+	// that reporting it does not allocate.
+	meeting meeting
+	overlap [1][2]*Segment
+	// observed holds the indices passed to an Observer's Block.
+	observed [2][]int
+}
+
+// NewSweeper returns a Sweeper that sweeps with opts.
+func NewSweeper(opts Options) *Sweeper {
+	return &Sweeper{opts: opts}
+}
+
+// Reset prepares the Sweeper for reuse with opts. It drops every reference to
+// segments from earlier calls, so they can be garbage collected, but keeps the
+// Sweeper's buffers.
+func (sw *Sweeper) Reset(opts Options) {
+	sw.release()
+	sw.opts = opts
+}
+
+// CountIntersections is CountIntersectionsWithOptions using the Sweeper's
+// Options and buffers.
+func (sw *Sweeper) CountIntersections(segments []Segment) int {
+	intersections := 0
+	sw.sweep(segments, sw.opts.resolve(segments), func(m *meeting) bool {
+		intersections += len(m.pairs)
+		return true
+	})
+	return intersections
+}
+
+// newEvent returns an event with the given fields, reusing a free one if
+// possible.
+func (sw *Sweeper) newEvent(p Point, typ EventType, seg1, seg2 *Segment) *Event {
+	var e *Event
+	if n := len(sw.free); n > 0 {
+		e = sw.free[n-1]
+		sw.free = sw.free[:n-1]
+	} else {
+		e = &Event{}
+	}
+	*e = Event{Point: p, Type: typ, Seg1: seg1, Seg2: seg2}
+	return e
+}
+
+// freeEvent makes e available for reuse.
+func (sw *Sweeper) freeEvent(e *Event) {
+	// Nil out pointers to prevent memory leaks.
+	e.Seg1 = nil
+	e.Seg2 = nil
+	sw.free = append(sw.free, e)
+}
+
+// release drops every reference the Sweeper holds to segments, keeping its
+// buffers. A sweep stopped early leaves events in the queue and segments in
+// the status.
+func (sw *Sweeper) release() {
+	for _, q := range [...]*EventQueue{&sw.queue, &sw.column.EventQueue} {
+		for _, e := range *q {
+			sw.freeEvent(e)
+		}
+		clear(*q)
+		*q = (*q)[:0]
+	}
+	if sw.status != nil {
+		sw.status.tree.clear()
+	}
+	clear(sw.segments)
+	sw.segments = sw.segments[:0]
+	clear(sw.starts[:cap(sw.starts)])
+	clear(sw.through[:cap(sw.through)])
+	clear(sw.after[:cap(sw.after)])
+	clear(sw.pairs[:cap(sw.pairs)])
+	sw.meeting = meeting{}
+	sw.overlap = [1][2]*Segment{}
+}
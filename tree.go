@@ -0,0 +1,391 @@
+package benott
+
+// rbTree is a red-black tree holding keys of type K in the order given by cmp.
+// Besides the usual parent and child links, its nodes are threaded into a
+// doubly linked list in key order, so the neighbours of a node are found in
+// O(1). Removed nodes are kept for reuse, so a tree that has reached its
+// working size stops allocating.
+//
+// The tree is purpose-built for the sweep-line status: keys are unique, and
+// the order of the keys may be changed in place with reverse.
+type rbTree[K any] struct {
+	root *rbNode[K]
+	// leaf is the sentinel standing for every missing child, as in CLRS. Its
+	// parent link is scratch space for deletion.
+	leaf        *rbNode[K]
+	first, last *rbNode[K]
+	size        int
+	cmp         func(a, b K) int
+	// free is a list of nodes ready for reuse, linked through their right
+	// child.
+	free *rbNode[K]
+}
+
+// rbNode is a node of an rbTree.
+type rbNode[K any] struct {
+	key                 K
+	left, right, parent *rbNode[K]
+	// prev and next link the nodes in key order. They are nil at the ends.
+	prev, next *rbNode[K]
+	red        bool
+}
+
+// newRBTree returns an empty tree ordered by cmp.
+func newRBTree[K any](cmp func(a, b K) int) *rbTree[K] {
+	leaf := &rbNode[K]{}
+	return &rbTree[K]{root: leaf, leaf: leaf, cmp: cmp}
+}
+
+// Len returns the number of keys in the tree.
+func (t *rbTree[K]) Len() int { return t.size }
+
+// find returns the node holding key, or nil if there is none.
+func (t *rbTree[K]) find(key K) *rbNode[K] {
+	for x := t.root; x != t.leaf; {
+		switch c := t.cmp(key, x.key); {
+		case c < 0:
+			x = x.left
+		case c > 0:
+			x = x.right
+		default:
+			return x
+		}
+	}
+	return nil
+}
+
+// insert adds key to the tree and returns its node. If an equal key is
+// already present, it is replaced.
+func (t *rbTree[K]) insert(key K) *rbNode[K] {
+	y, c := t.leaf, 0
+	for x := t.root; x != t.leaf; {
+		y = x
+		c = t.cmp(key, x.key)
+		switch {
+		case c < 0:
+			x = x.left
+		case c > 0:
+			x = x.right
+		default:
+			x.key = key
+			return x
+		}
+	}
+
+	z := t.alloc(key)
+	z.parent = y
+	switch {
+	case y == t.leaf:
+		t.root = z
+		t.first, t.last = z, z
+	case c < 0:
+		// A new left child comes just before its parent in key order.
+		y.left = z
+		z.prev, z.next = y.prev, y
+		if y.prev != nil {
+			y.prev.next = z
+		} else {
+			t.first = z
+		}
+		y.prev = z
+	default:
+		y.right = z
+		z.prev, z.next = y, y.next
+		if y.next != nil {
+			y.next.prev = z
+		} else {
+			t.last = z
+		}
+		y.next = z
+	}
+	t.size++
+	t.insertFixup(z)
+	return z
+}
+
+// remove deletes key from the tree and reports whether it was present.
+func (t *rbTree[K]) remove(key K) bool {
+	z := t.find(key)
+	if z == nil {
+		return false
+	}
+	t.delete(z)
+	return true
+}
+
+// delete removes the node z from the tree. Other nodes keep their keys, so
+// references to them stay valid.
+func (t *rbTree[K]) delete(z *rbNode[K]) {
+	if z.prev != nil {
+		z.prev.next = z.next
+	} else {
+		t.first = z.next
+	}
+	if z.next != nil {
+		z.next.prev = z.prev
+	} else {
+		t.last = z.prev
+	}
+
+	y, yRed := z, z.red
+	var x *rbNode[K]
+	switch {
+	case z.left == t.leaf:
+		x = z.right
+		t.transplant(z, z.right)
+	case z.right == t.leaf:
+		x = z.left
+		t.transplant(z, z.left)
+	default:
+		// The successor of z takes its place.
+		y = z.next
+		yRed = y.red
+		x = y.right
+		if y.parent == z {
+			x.parent = y
+		} else {
+			t.transplant(y, y.right)
+			y.right = z.right
+			y.right.parent = y
+		}
+		t.transplant(z, y)
+		y.left = z.left
+		y.left.parent = y
+		y.red = z.red
+	}
+	if !yRed {
+		t.deleteFixup(x)
+	}
+	t.size--
+	t.release(z)
+}
+
+// reverse reverses the order of the keys held by the block of consecutive
+// nodes from lo to hi, by swapping keys between the nodes. The caller must
+// ensure that the reversed keys are in order again.
+func (t *rbTree[K]) reverse(lo, hi *rbNode[K]) {
+	for lo != hi {
+		lo.key, hi.key = hi.key, lo.key
+		if lo.next == hi {
+			return
+		}
+		lo, hi = lo.next, hi.prev
+	}
+}
+
+// pass moves the block of n consecutive nodes starting at first past a point
+// where all of their keys meet. Keys for which ends reports true stop at the
+// point and are deleted. The others swap order there, so the block is
+// reversed in place by swapping keys between its nodes, and nothing needs to
+// be compared on the sweep line.
+//
+// after orders keys meeting at the point just past it. It only checks each
+// adjacent pair of the reversed block, and restores the order of keys that
+// meet without crossing, such as collinear keys, which keep their order.
+func (t *rbTree[K]) pass(first *rbNode[K], n int, ends func(K) bool, after func(a, b K) int) {
+	var lo, hi *rbNode[K]
+	for node := first; n > 0; n-- {
+		next := node.next
+		if ends(node.key) {
+			t.delete(node)
+		} else {
+			if lo == nil {
+				lo = node
+			}
+			hi = node
+		}
+		node = next
+	}
+	if lo == nil {
+		return
+	}
+	t.reverse(lo, hi)
+
+	// Keys that do not cross were reversed along with the others, leaving
+	// them in descending runs. Turn each of those back, then move any key
+	// still out of order into place.
+	for a := lo; a != hi; {
+		b := a
+		for b != hi && after(b.key, b.next.key) > 0 {
+			b = b.next
+		}
+		if b == a {
+			a = a.next
+			continue
+		}
+		t.reverse(a, b)
+		if b == hi {
+			break
+		}
+		a = b.next
+	}
+	for node := lo; node != hi; node = node.next {
+		for m := node.next; m != lo && after(m.prev.key, m.key) > 0; m = m.prev {
+			m.prev.key, m.key = m.key, m.prev.key
+		}
+	}
+}
+
+// next returns the node following n in key order, or the first node if n is
+// nil.
+func (t *rbTree[K]) next(n *rbNode[K]) *rbNode[K] {
+	if n == nil {
+		return t.first
+	}
+	return n.next
+}
+
+// keyOf returns the key held by n, or the zero K if n is nil.
+func keyOf[K any](n *rbNode[K]) K {
+	if n == nil {
+		var zero K
+		return zero
+	}
+	return n.key
+}
+
+// clear removes every key from the tree, keeping the nodes for reuse.
+func (t *rbTree[K]) clear() {
+	for n := t.first; n != nil; {
+		next := n.next
+		t.release(n)
+		n = next
+	}
+	t.root, t.first, t.last, t.size = t.leaf, nil, nil, 0
+}
+
+// alloc returns a red node holding key, reusing a released node if possible.
+func (t *rbTree[K]) alloc(key K) *rbNode[K] {
+	n := t.free
+	if n != nil {
+		t.free = n.right
+	} else {
+		n = &rbNode[K]{}
+	}
+	*n = rbNode[K]{key: key, left: t.leaf, right: t.leaf, red: true}
+	return n
+}
+
+// release makes n available for reuse.
+func (t *rbTree[K]) release(n *rbNode[K]) {
+	*n = rbNode[K]{right: t.free} // Drop the key so it can be collected.
+	t.free = n
+}
+
+// transplant replaces the subtree rooted at u with the one rooted at v.
+func (t *rbTree[K]) transplant(u, v *rbNode[K]) {
+	switch {
+	case u.parent == t.leaf:
+		t.root = v
+	case u == u.parent.left:
+		u.parent.left = v
+	default:
+		u.parent.right = v
+	}
+	v.parent = u.parent
+}
+
+func (t *rbTree[K]) rotateLeft(x *rbNode[K]) {
+	y := x.right
+	x.right = y.left
+	if y.left != t.leaf {
+		y.left.parent = x
+	}
+	t.transplant(x, y)
+	y.left = x
+	x.parent = y
+}
+
+func (t *rbTree[K]) rotateRight(x *rbNode[K]) {
+	y := x.left
+	x.left = y.right
+	if y.right != t.leaf {
+		y.right.parent = x
+	}
+	t.transplant(x, y)
+	y.right = x
+	x.parent = y
+}
+
+// insertFixup restores the red-black properties after inserting z.
+func (t *rbTree[K]) insertFixup(z *rbNode[K]) {
+	for z.parent.red {
+		if z.parent == z.parent.parent.left {
+			y := z.parent.parent.right
+			if y.red {
+				z.parent.red, y.red, z.parent.parent.red = false, false, true
+				z = z.parent.parent
+				continue
+			}
+			if z == z.parent.right {
+				z = z.parent
+				t.rotateLeft(z)
+			}
+			z.parent.red, z.parent.parent.red = false, true
+			t.rotateRight(z.parent.parent)
+		} else {
+			y := z.parent.parent.left
+			if y.red {
+				z.parent.red, y.red, z.parent.parent.red = false, false, true
+				z = z.parent.parent
+				continue
+			}
+			if z == z.parent.left {
+				z = z.parent
+				t.rotateRight(z)
+			}
+			z.parent.red, z.parent.parent.red = false, true
+			t.rotateLeft(z.parent.parent)
+		}
+	}
+	t.root.red = false
+}
+
+// deleteFixup restores the red-black properties after a black node was
+// removed above x.
+func (t *rbTree[K]) deleteFixup(x *rbNode[K]) {
+	for x != t.root && !x.red {
+		if x == x.parent.left {
+			w := x.parent.right
+			if w.red {
+				w.red, x.parent.red = false, true
+				t.rotateLeft(x.parent)
+				w = x.parent.right
+			}
+			if !w.left.red && !w.right.red {
+				w.red = true
+				x = x.parent
+				continue
+			}
+			if !w.right.red {
+				w.left.red, w.red = false, true
+				t.rotateRight(w)
+				w = x.parent.right
+			}
+			w.red, x.parent.red, w.right.red = x.parent.red, false, false
+			t.rotateLeft(x.parent)
+			x = t.root
+		} else {
+			w := x.parent.left
+			if w.red {
+				w.red, x.parent.red = false, true
+				t.rotateRight(x.parent)
+				w = x.parent.left
+			}
+			if !w.right.red && !w.left.red {
+				w.red = true
+				x = x.parent
+				continue
+			}
+			if !w.left.red {
+				w.right.red, w.red = false, true
+				t.rotateLeft(w)
+				w = x.parent.left
+			}
+			w.red, x.parent.red, w.left.red = x.parent.red, false, false
+			t.rotateRight(x.parent)
+			x = t.root
+		}
+	}
+	x.red = false
+}
@@ -0,0 +1,109 @@
+package benott
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrNonFinite is reported for a segment with a NaN or infinite coordinate.
+	// Such coordinates cannot be ordered along the sweep line.
+	ErrNonFinite = errors.New("non-finite coordinate")
+	// ErrZeroLength is reported for a segment whose endpoints coincide, when
+	// Options.ZeroLength is RejectZeroLength.
+	ErrZeroLength = errors.New("zero-length segment")
+)
+
+// SegmentError describes an invalid input segment. Err is ErrNonFinite or
+// ErrZeroLength, so callers can test for either with errors.Is and recover
+// the offending segment with errors.As.
+type SegmentError struct {
+	// Index is the position of the segment in the input slice.
+	Index int
+	// Err is the reason the segment is invalid.
+	Err error
+}
+
+func (e *SegmentError) Error() string {
+	return fmt.Sprintf("benott: segment %d: %v", e.Index, e.Err)
+}
+
+func (e *SegmentError) Unwrap() error { return e.Err }
+
+// ZeroLengthPolicy selects how segments whose endpoints coincide, within the
+// tolerance or exactly in robust mode, are handled.
+type ZeroLengthPolicy int
+
+const (
+	// KeepZeroLength sweeps zero-length segments as points. Each one
+	// intersects every segment passing through its point, including other
+	// zero-length segments there. For the endpoint policy the point counts as
+	// the interior of the zero-length segment, so under the default policy it
+	// also intersects segments that merely end there. This is the default.
+	KeepZeroLength ZeroLengthPolicy = iota
+	// SkipZeroLength leaves zero-length segments out of the sweep entirely.
+	SkipZeroLength
+	// RejectZeroLength makes Validate and CountIntersectionsChecked report a
+	// zero-length segment as an ErrZeroLength error. Functions that cannot
+	// return an error treat it like KeepZeroLength.
+	RejectZeroLength
+)
+
+// Validate checks that every segment can be swept with opts. It returns a
+// *SegmentError for the first segment with a NaN or infinite coordinate and,
+// if there is none and opts.ZeroLength is RejectZeroLength, for the first
+// zero-length segment. It returns nil if all segments are valid.
+func Validate(segments []Segment, opts Options) error {
+	for i := range segments {
+		if !finite(&segments[i]) {
+			return &SegmentError{Index: i, Err: ErrNonFinite}
+		}
+	}
+	if opts.ZeroLength != RejectZeroLength {
+		return nil
+	}
+	cfg := opts.resolve(segments)
+	for i := range segments {
+		if cfg.zeroLength(&segments[i]) {
+			return &SegmentError{Index: i, Err: ErrZeroLength}
+		}
+	}
+	return nil
+}
+
+// CountIntersectionsChecked is CountIntersectionsWithOptions for untrusted
+// input. It validates the segments first and returns the error from Validate
+// instead of silently leaving invalid segments out of the count.
+func CountIntersectionsChecked(segments []Segment, opts Options) (int, error) {
+	if err := Validate(segments, opts); err != nil {
+		return 0, err
+	}
+	return CountIntersectionsWithOptions(segments, opts), nil
+}
+
+// finite reports whether every coordinate of s is finite.
+func finite(s *Segment) bool {
+	for _, v := range [...]float64{s.P1.X, s.P1.Y, s.P2.X, s.P2.Y} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// zeroLength reports whether the endpoints of s coincide: exactly in robust
+// mode, or within the tolerance otherwise.
+func (c config) zeroLength(s *Segment) bool {
+	if c.robust {
+		return s.P1 == s.P2
+	}
+	return near(s.P1, s.P2, c.eps)
+}
+
+// skips reports whether s is left out of the sweep: segments with a NaN or
+// infinite coordinate always are, since they cannot be ordered along the
+// sweep line, and zero-length segments under SkipZeroLength.
+func (c config) skips(s *Segment) bool {
+	return !finite(s) || (c.zeroLengths == SkipZeroLength && c.zeroLength(s))
+}
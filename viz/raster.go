@@ -0,0 +1,128 @@
+package viz
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// rgb is a color of the palette shared by the SVG and raster drawings.
+type rgb = color.RGBA
+
+var (
+	background        = rgb{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	segmentColor      = rgb{R: 0x4a, G: 0x6f, B: 0xa5, A: 0xff}
+	statusColor       = rgb{R: 0xe0, G: 0x7b, B: 0x00, A: 0xff}
+	intersectionColor = rgb{R: 0xd6, G: 0x27, B: 0x28, A: 0xff}
+	sweepColor        = rgb{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff}
+)
+
+// hex returns c in the #rrggbb notation.
+func hex(c rgb) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// active returns the set of segments in the status of the scene's frame.
+func (s *Scene) active() map[int]struct{} {
+	active := make(map[int]struct{})
+	if s.Frame != nil {
+		for _, i := range s.Frame.Status {
+			active[i] = struct{}{}
+		}
+	}
+	return active
+}
+
+// Image rasterizes the scene, with the colors of WriteSVG but without any
+// text. The segments in the status of the scene's frame are marked by a tick
+// where they cross the sweep line instead of a label.
+func (s *Scene) Image() *image.RGBA {
+	t := s.layout()
+	img := image.NewRGBA(image.Rect(0, 0, t.width, t.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	active := s.active()
+	for i, seg := range s.Segments {
+		if _, ok := active[i]; !ok {
+			rasterLine(img, t, seg.P1, seg.P2, segmentColor, 1.5, 0)
+		}
+	}
+	for i, seg := range s.Segments {
+		if _, ok := active[i]; ok {
+			rasterLine(img, t, seg.P1, seg.P2, statusColor, 2.5, 0)
+		}
+	}
+
+	for _, r := range s.Intersections {
+		if !s.found(r.Point) {
+			continue
+		}
+		if r.Kind == benott.CollinearOverlap {
+			rasterLine(img, t, r.Overlap[0], r.Overlap[1], intersectionColor, 5, 0)
+			continue
+		}
+		x, y := t.at(r.Point)
+		disc(img, x, y, 3.5, intersectionColor)
+	}
+
+	if f := s.Frame; f != nil {
+		x, y := t.at(f.Point)
+		line(img, x, 0, x, float64(t.height), sweepColor, 1, 6)
+		disc(img, x, y, 4, sweepColor)
+		for _, i := range f.Status {
+			if i < 0 || i >= len(s.Segments) {
+				continue
+			}
+			_, y := t.at(benott.Point{X: f.Point.X, Y: yAt(s.Segments[i], f.Point)})
+			line(img, x-6, y, x+6, y, statusColor, 2, 0)
+		}
+	}
+	return img
+}
+
+// WritePNG writes the scene to w as a PNG image, drawn by Image.
+func (s *Scene) WritePNG(w io.Writer) error {
+	return png.Encode(w, s.Image())
+}
+
+// rasterLine draws the segment from p to q in input coordinates.
+func rasterLine(img *image.RGBA, t transform, p, q benott.Point, c rgb, width, dash float64) {
+	x1, y1 := t.at(p)
+	x2, y2 := t.at(q)
+	line(img, x1, y1, x2, y2, c, width, dash)
+}
+
+// line draws a line of the given width between two pixel positions by
+// stamping discs along it. With a positive dash, it alternates drawn and
+// blank stretches of that length.
+func line(img *image.RGBA, x1, y1, x2, y2 float64, c rgb, width, dash float64) {
+	length := math.Hypot(x2-x1, y2-y1)
+	steps := int(math.Ceil(length*2)) + 1
+	for i := range steps {
+		d := length * float64(i) / float64(max(steps-1, 1))
+		if dash > 0 && int(d/dash)%2 == 1 {
+			continue
+		}
+		f := d / math.Max(length, 1)
+		disc(img, x1+f*(x2-x1), y1+f*(y2-y1), width/2, c)
+	}
+}
+
+// disc fills the pixels whose centers lie within radius r of (x, y), and at
+// least the pixel containing it.
+func disc(img *image.RGBA, x, y, r float64, c rgb) {
+	img.SetRGBA(int(math.Floor(x)), int(math.Floor(y)), c)
+	for py := int(math.Floor(y - r)); py <= int(math.Ceil(y+r)); py++ {
+		for px := int(math.Floor(x - r)); px <= int(math.Ceil(x+r)); px++ {
+			if math.Hypot(float64(px)+0.5-x, float64(py)+0.5-y) <= r {
+				img.SetRGBA(px, py, c)
+			}
+		}
+	}
+}
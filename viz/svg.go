@@ -0,0 +1,83 @@
+package viz
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// WriteSVG writes the scene to w as an SVG document.
+//
+// Segments are drawn in blue, and the segments in the status of the scene's
+// frame in orange, each labelled with its position in the status where it
+// crosses the sweep line. The status is also listed from bottom to top in the
+// top left corner. Intersection points are red dots and collinear overlaps
+// thick red lines.
+func (s *Scene) WriteSVG(w io.Writer) error {
+	t := s.layout()
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", t.width, t.height, t.width, t.height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hex(background))
+
+	active := s.active()
+	b.WriteString(`<g stroke-linecap="round">` + "\n")
+	for i, seg := range s.Segments {
+		if _, ok := active[i]; ok {
+			continue
+		}
+		svgLine(&b, t, seg.P1, seg.P2, segmentColor, 1.5, "")
+	}
+	for i, seg := range s.Segments {
+		if _, ok := active[i]; ok {
+			svgLine(&b, t, seg.P1, seg.P2, statusColor, 2.5, "")
+		}
+	}
+	b.WriteString("</g>\n")
+
+	for _, r := range s.Intersections {
+		if !s.found(r.Point) {
+			continue
+		}
+		if r.Kind == benott.CollinearOverlap {
+			svgLine(&b, t, r.Overlap[0], r.Overlap[1], intersectionColor, 5, ` stroke-opacity="0.6"`)
+			continue
+		}
+		x, y := t.at(r.Point)
+		fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="3.5" fill="%s"/>`+"\n", x, y, hex(intersectionColor))
+	}
+
+	b.WriteString(`<g font-family="sans-serif" font-size="11">` + "\n")
+	if s.Style.Labels {
+		for i, seg := range s.Segments {
+			x, y := t.at(seg.P1)
+			fmt.Fprintf(&b, `<text x="%.2f" y="%.2f" fill="%s">%d</text>`+"\n", x+3, y-3, hex(segmentColor), i)
+		}
+	}
+	if f := s.Frame; f != nil {
+		x, y := t.at(f.Point)
+		fmt.Fprintf(&b, `<line x1="%.2f" y1="0" x2="%.2f" y2="%d" stroke="%s" stroke-dasharray="6 4"/>`+"\n", x, x, t.height, hex(sweepColor))
+		fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="4" fill="none" stroke="%s" stroke-width="2"/>`+"\n", x, y, hex(sweepColor))
+		for rank, i := range f.Status {
+			if i < 0 || i >= len(s.Segments) {
+				continue
+			}
+			_, y := t.at(benott.Point{X: f.Point.X, Y: yAt(s.Segments[i], f.Point)})
+			fmt.Fprintf(&b, `<text x="%.2f" y="%.2f" fill="%s">%d</text>`+"\n", x+4, y-3, hex(statusColor), rank)
+		}
+		fmt.Fprintf(&b, `<text x="4" y="14" fill="%s">x = %g, status: %s</text>`+"\n", hex(sweepColor), f.Point.X, strings.Trim(fmt.Sprint(f.Status), "[]"))
+	}
+	b.WriteString("</g>\n</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// svgLine writes a line from p to q with the given color, width and extra
+// attributes.
+func svgLine(b *strings.Builder, t transform, p, q benott.Point, c rgb, width float64, attrs string) {
+	x1, y1 := t.at(p)
+	x2, y2 := t.at(q)
+	fmt.Fprintf(b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%g"%s/>`+"\n", x1, y1, x2, y2, hex(c), width, attrs)
+}
@@ -0,0 +1,154 @@
+// Package viz draws segments, their intersections and the state of the
+// Bentley-Ottmann sweep, to help look at inputs on which the sweep goes wrong.
+//
+// A Scene is written as SVG, or rasterized with the standard library image
+// packages and written as PNG. Trace records a Frame at every event point of
+// the sweep; setting one on a Scene shows the sweep line there and the
+// segments in the sweep-line status, so a sequence of frames steps through the
+// sweep.
+package viz
+
+import (
+	"math"
+	"slices"
+
+	"github.com/GregoryKogan/benott"
+)
+
+// Default sizes used for the zero fields of Style.
+const (
+	defaultSize   = 800
+	defaultMargin = 20
+)
+
+// Scene is a set of segments and their intersections, ready to be drawn.
+type Scene struct {
+	Segments      []benott.Segment
+	Intersections []benott.IntersectionResult
+	// Frame, if set, shows the sweep at one event point: the sweep line, the
+	// segments in the status, labelled with their position in it, and only
+	// the intersections found up to that point.
+	Frame *Frame
+	Style Style
+}
+
+// Style sets the size and annotations of a drawing. Zero fields select the
+// defaults.
+type Style struct {
+	// Size is the length in pixels of the longer side of the drawing, 800 by
+	// default.
+	Size int
+	// Margin is the blank space around the drawing in pixels, 20 by default.
+	Margin int
+	// Labels writes the index of every segment next to its first endpoint.
+	// Text is only drawn in SVG.
+	Labels bool
+}
+
+// NewScene returns a Scene showing segments and their intersections, as found
+// by benott.FindIntersectionsWithOptions with opts.
+func NewScene(segments []benott.Segment, opts benott.Options) *Scene {
+	return &Scene{Segments: segments, Intersections: benott.FindIntersectionsWithOptions(segments, opts)}
+}
+
+// Frame is the state of the sweep once it has moved past an event point.
+type Frame struct {
+	// Point is the event point. The sweep line is the vertical line through
+	// it.
+	Point benott.Point
+	// Status holds the indices of the segments in the sweep-line status, from
+	// bottom to top.
+	Status []int
+}
+
+// Trace sweeps segments with opts and returns a Frame for every event point,
+// in the order the sweep reaches them. It replaces any Observer in opts.
+func Trace(segments []benott.Segment, opts benott.Options) []Frame {
+	t := &tracer{}
+	opts.Observer = t
+	benott.CountIntersectionsWithOptions(segments, opts)
+	return t.frames
+}
+
+// tracer is a benott.StatusObserver recording a Frame at every event point.
+type tracer struct {
+	frames []Frame
+}
+
+func (t *tracer) Event(benott.Point, benott.EventType, int, int)                 {}
+func (t *tracer) Add(benott.Point, int)                                          {}
+func (t *tracer) Remove(benott.Point, int)                                       {}
+func (t *tracer) Check(benott.Point, int, int, benott.Point, benott.CheckResult) {}
+func (t *tracer) Block(benott.Point, []int, []int)                               {}
+func (t *tracer) Status(p benott.Point, segments []int) {
+	t.frames = append(t.frames, Frame{Point: p, Status: slices.Clone(segments)})
+}
+
+// found reports whether the sweep has reached p at the scene's frame.
+func (s *Scene) found(p benott.Point) bool {
+	if s.Frame == nil {
+		return true
+	}
+	f := s.Frame.Point
+	return p.X < f.X || (p.X == f.X && p.Y <= f.Y)
+}
+
+// yAt returns the height of seg on the vertical line through p, or p.Y for a
+// vertical segment, clamped to its extent.
+func yAt(seg benott.Segment, p benott.Point) float64 {
+	if seg.P1.X == seg.P2.X {
+		return math.Max(math.Min(seg.P1.Y, seg.P2.Y), math.Min(p.Y, math.Max(seg.P1.Y, seg.P2.Y)))
+	}
+	t := (p.X - seg.P1.X) / (seg.P2.X - seg.P1.X)
+	return seg.P1.Y + t*(seg.P2.Y-seg.P1.Y)
+}
+
+// transform maps input coordinates to pixels, with y pointing down.
+type transform struct {
+	minX, maxY    float64
+	scale, margin float64
+	width, height int
+}
+
+// layout fits the scene's segments into its Style.
+func (s *Scene) layout() transform {
+	size, margin := s.Style.Size, s.Style.Margin
+	if size <= 0 {
+		size = defaultSize
+	}
+	if margin <= 0 {
+		margin = defaultMargin
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, seg := range s.Segments {
+		for _, p := range [2]benott.Point{seg.P1, seg.P2} {
+			minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+			minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+		}
+	}
+	if len(s.Segments) == 0 {
+		minX, maxX, minY, maxY = 0, 1, 0, 1
+	}
+	w, h := maxX-minX, maxY-minY
+	if w == 0 && h == 0 {
+		// A drawing of a single point is centred in a unit square.
+		minX, maxY = minX-0.5, maxY+0.5
+		w, h = 1, 1
+	}
+
+	inner := float64(size - 2*margin)
+	scale := inner / math.Max(w, h)
+	return transform{
+		minX: minX, maxY: maxY,
+		scale: scale, margin: float64(margin),
+		width:  int(math.Ceil(w*scale)) + 2*margin,
+		height: int(math.Ceil(h*scale)) + 2*margin,
+	}
+}
+
+// at returns the pixel position of p.
+func (t transform) at(p benott.Point) (x, y float64) {
+	return t.margin + (p.X-t.minX)*t.scale, t.margin + (t.maxY-p.Y)*t.scale
+}
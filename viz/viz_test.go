@@ -0,0 +1,142 @@
+package viz_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/GregoryKogan/benott"
+	"github.com/GregoryKogan/benott/viz"
+)
+
+// star holds three segments crossing at (5, 5).
+var star = []benott.Segment{
+	{P1: benott.Point{X: 0, Y: 0}, P2: benott.Point{X: 10, Y: 10}},
+	{P1: benott.Point{X: 0, Y: 10}, P2: benott.Point{X: 10, Y: 0}},
+	{P1: benott.Point{X: 0, Y: 5}, P2: benott.Point{X: 10, Y: 5}},
+}
+
+// elements counts the elements of an XML document by name.
+func elements(t *testing.T, doc []byte) map[string]int {
+	t.Helper()
+	counts := make(map[string]int)
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return counts
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG: %v", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+// --- Scenes ---
+
+func TestWriteSVG(t *testing.T) {
+	scene := viz.NewScene(star, benott.Options{})
+	var buf bytes.Buffer
+	if err := scene.WriteSVG(&buf); err != nil {
+		t.Fatalf("WriteSVG failed: %v", err)
+	}
+
+	// The three crossings share one point, drawn once.
+	counts := elements(t, buf.Bytes())
+	if counts["svg"] != 1 || counts["line"] != 3 || counts["circle"] != 1 || counts["text"] != 0 {
+		t.Errorf("Expected 3 lines and 1 circle, got %v", counts)
+	}
+}
+
+func TestWriteSVGOverlapsAndLabels(t *testing.T) {
+	segments := []benott.Segment{
+		{P1: benott.Point{X: 0, Y: 0}, P2: benott.Point{X: 4, Y: 0}},
+		{P1: benott.Point{X: 2, Y: 0}, P2: benott.Point{X: 6, Y: 0}},
+	}
+	scene := viz.NewScene(segments, benott.Options{ReportOverlaps: true})
+	scene.Style.Labels = true
+	var buf bytes.Buffer
+	if err := scene.WriteSVG(&buf); err != nil {
+		t.Fatalf("WriteSVG failed: %v", err)
+	}
+
+	counts := elements(t, buf.Bytes())
+	if counts["line"] != 3 || counts["circle"] != 0 || counts["text"] != 2 {
+		t.Errorf("Expected 3 lines and 2 labels, got %v", counts)
+	}
+}
+
+func TestWriteSVGFrame(t *testing.T) {
+	scene := viz.NewScene(star, benott.Options{})
+	scene.Frame = &viz.Frame{Point: benott.Point{X: 0, Y: 10}, Status: []int{0, 2, 1}}
+	var buf bytes.Buffer
+	if err := scene.WriteSVG(&buf); err != nil {
+		t.Fatalf("WriteSVG failed: %v", err)
+	}
+
+	// Before the crossing, no intersection is drawn; the sweep line and event
+	// circle are added, with a label per segment in the status and a caption.
+	counts := elements(t, buf.Bytes())
+	if counts["line"] != 4 || counts["circle"] != 1 || counts["text"] != 4 {
+		t.Errorf("Expected 4 lines, 1 circle and 4 labels, got %v", counts)
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	scene := viz.NewScene(star, benott.Options{})
+	scene.Style = viz.Style{Size: 120, Margin: 10}
+	var buf bytes.Buffer
+	if err := scene.WritePNG(&buf); err != nil {
+		t.Fatalf("WritePNG failed: %v", err)
+	}
+
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Invalid PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 120 || size.Y != 120 {
+		t.Errorf("Expected a 120x120 image, got %v", size)
+	}
+	// The crossing is in the middle of the image, and the corners are blank.
+	if r, g, b, _ := img.At(60, 60).RGBA(); r>>8 != 0xd6 || g>>8 != 0x27 || b>>8 != 0x28 {
+		t.Errorf("Expected the intersection color at the crossing, got %v", img.At(60, 60))
+	}
+	if c := color.RGBAModel.Convert(img.At(1, 1)); c != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("Expected a blank corner, got %v", c)
+	}
+}
+
+func TestEmptyScene(t *testing.T) {
+	scene := viz.NewScene(nil, benott.Options{})
+	if size := scene.Image().Bounds().Size(); size.X != 800 || size.Y != 800 {
+		t.Errorf("Expected an 800x800 image, got %v", size)
+	}
+	if err := scene.WriteSVG(io.Discard); err != nil {
+		t.Errorf("WriteSVG failed: %v", err)
+	}
+}
+
+// --- Traces ---
+
+func TestTrace(t *testing.T) {
+	frames := viz.Trace(star, benott.Options{})
+	expected := []viz.Frame{
+		{Point: benott.Point{X: 0, Y: 0}, Status: []int{0}},
+		{Point: benott.Point{X: 0, Y: 5}, Status: []int{0, 2}},
+		{Point: benott.Point{X: 0, Y: 10}, Status: []int{0, 2, 1}},
+		{Point: benott.Point{X: 5, Y: 5}, Status: []int{1, 2, 0}},
+		{Point: benott.Point{X: 10, Y: 0}, Status: []int{2, 0}},
+		{Point: benott.Point{X: 10, Y: 5}, Status: []int{0}},
+		{Point: benott.Point{X: 10, Y: 10}, Status: []int{}},
+	}
+	if !reflect.DeepEqual(frames, expected) {
+		t.Errorf("Expected frames %v, got %v", expected, frames)
+	}
+}